@@ -2,53 +2,153 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"learngolang/src/config"
+	adminHandler "learngolang/src/handler/admin"
 	restHandler "learngolang/src/handler/rest"
+	schedHandler "learngolang/src/handler/scheduler"
+	"os"
+	"strings"
 
-	// schedHandler "learngolang/src/handler/scheduler"
 	"learngolang/src/preference"
 	"learngolang/src/repository"
 	"learngolang/src/service"
 
-	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
-	"github.com/redis/go-redis/v9"
 )
 
 var (
-	minJitter int
-	maxJitter int
-	sql0      *sqlx.DB
-	redis0    *redis.Client
-	redis1    *redis.Client
-	redis2    *redis.Client
-	scheduler *config.Scheduler
-	app       config.App
+	minJitter     int
+	maxJitter     int
+	showConfig    bool
+	configPath    string
+	configProfile string
+	app           config.App
 )
 
-func init() {
+// setup builds the app from the flags and the config, it runs from main so the
+// tests of this package do not start it.
+func setup() {
 	flag.IntVar(&minJitter, "minSleep", DefaultMinJitter, "min. sleep duration during app initialization")
 	flag.IntVar(&maxJitter, "maxSleep", DefaultMaxJitter, "max. sleep duration during app initialization")
+	flag.StringVar(&configPath, "config", "", "config file path, defaults to $APP_CONFIG then config.yaml")
+	flag.StringVar(&configProfile, "profile", "", "config profile overlay, e.g. production loads config.production.yaml, defaults to $APP_PROFILE")
+	flag.BoolVar(&showConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+	configFlags := bindConfigFlags(flag.CommandLine)
 	flag.Parse()
 
-	sleepWithJitter(minJitter, maxJitter)
+	// "config validate" checks the config and exits, flags may follow the subcommand
+	validateOnly := false
+	// "debug sign" needs the config for the signing key
+	var debugArgs []string
+	if args := flag.Args(); len(args) > 0 {
+		switch {
+		case len(args) >= 2 && args[0] == "config" && args[1] == "validate":
+			validateOnly = true
+			if err := flag.CommandLine.Parse(args[2:]); err != nil {
+				exitWithError(err)
+			}
+		case args[0] == "debug":
+			debugArgs = args[1:]
+		case args[0] == "secrets":
+			if err := runSecretsCommand(args[1:]); err != nil {
+				exitWithError(err)
+			}
+
+			os.Exit(0)
+		case args[0] == "messages":
+			if err := runMessagesCommand(args[1:]); err != nil {
+				exitWithError(err)
+			}
+
+			os.Exit(0)
+		default:
+			exitWithError(fmt.Errorf("unknown command %q, expected \"config validate\", \"debug\", \"secrets\" or \"messages\"", strings.Join(args, " ")))
+		}
+	}
 
-	// Config Initialization
-	conf, err := InitConfig()
+	// Config Initialization, file values are overridden by APP_* env vars then by flags
+	conf, err := InitConfig(configPath, configProfile, configFlags)
 	if err != nil {
-		panic(err)
+		exitWithError(err)
+	}
+
+	if showConfig {
+		if err := printConfig(conf); err != nil {
+			exitWithError(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if debugArgs != nil {
+		if err := runDebugCommand(conf, debugArgs); err != nil {
+			exitWithError(err)
+		}
+
+		os.Exit(0)
+	}
+
+	// Config Validation, nothing is connected yet
+	if err := conf.Validate(); err != nil {
+		exitWithError(err)
+	}
+
+	if validateOnly {
+		fmt.Println("config is valid")
+		os.Exit(0)
+	}
+
+	// The jitter only spreads cold starts, a handed over socket is already accepting
+	if !config.IsInheritedStart() {
+		sleepWithJitter(minJitter, maxJitter)
 	}
 
 	// Logger Initialization
-	log := config.InitLogger(conf.Logger)
+	log := config.InitLogger(conf.Logger, conf.Server.Mode)
+
+	// Metrics Initialization
+	metrics := config.InitMetrics(log, conf.Metrics)
+
+	// Health Check Initialization
+	health := config.InitHealth(log, conf.Health)
+
+	// Tracing Initialization
+	tracing := config.InitTracing(log, conf.Tracing)
+
+	// App Initialization, components are stopped in reverse registration order
+	app = config.InitGrace(log, conf.Server, health)
+
+	// registered first so the spans of the draining requests are still exported
+	app.Register(config.TracingHook(tracing))
 
 	// SQL Initialization
-	sql0 = config.InitDB(log, conf.Postgres)
+	sql0 := config.InitDB(log, conf.Postgres)
+	if sql0 != nil {
+		metrics.RegisterDB(conf.Postgres.Driver, sql0.DB)
+	}
+
+	app.Register(config.SQLHook(conf.Postgres.Driver, sql0))
 
 	// Redis Initialization
-	redis0 = config.InitRedis(log, conf.Redis, preference.REDIS_APPS)
-	redis1 = config.InitRedis(log, conf.Redis, preference.REDIS_AUTH)
-	redis2 = config.InitRedis(log, conf.Redis, preference.REDIS_LIMITER)
+	redis0 := config.InitRedis(log, conf.Redis, preference.REDIS_APPS)
+	redis1 := config.InitRedis(log, conf.Redis, preference.REDIS_AUTH)
+	redis2 := config.InitRedis(log, conf.Redis, preference.REDIS_LIMITER)
+	metrics.RegisterRedis(preference.REDIS_APPS, redis0)
+	metrics.RegisterRedis(preference.REDIS_AUTH, redis1)
+	metrics.RegisterRedis(preference.REDIS_LIMITER, redis2)
+
+	app.Register(
+		config.RedisHook("redis_"+preference.REDIS_APPS, redis0),
+		config.RedisHook("redis_"+preference.REDIS_AUTH, redis1),
+		config.RedisHook("redis_"+preference.REDIS_LIMITER, redis2),
+	)
+
+	// Health Checks
+	health.AddSQL(conf.Postgres.Driver, sql0)
+	health.AddRedis("redis_"+preference.REDIS_APPS, redis0)
+	health.AddRedis("redis_"+preference.REDIS_AUTH, redis1)
+	health.AddRedis("redis_"+preference.REDIS_LIMITER, redis2)
 
 	// Query Loader Initialization
 	queryLoader, err := config.InitQueryLoader(log, conf.Queries)
@@ -56,8 +156,13 @@ func init() {
 		log.Panic().Err(err).Msg("Failed to load queries")
 	}
 
+	// Message Catalogs Initialization
+	if err := config.InitMessages(log, conf.Messages); err != nil {
+		log.Panic().Err(err).Msg("Failed to load message catalogs")
+	}
+
 	// Initialize dependencies
-	repository := repository.InitRepository(sql0, redis0, queryLoader, conf.Redis.CacheTTL)
+	repository := repository.InitRepository(sql0, redis0, queryLoader, conf.Redis.CacheTTL, metrics)
 	service := service.InitService(repository)
 
 	// Initialize validator
@@ -67,47 +172,81 @@ func init() {
 	auth := config.InitAuth(log, conf.Auth, redis1)
 
 	// Middleware Initialization
-	middleware := config.InitMiddleware(log, auth, redis2)
+	middleware := config.InitMiddleware(log, conf.Middleware, conf.Server.Mode, auth, redis2, metrics)
+
+	// HTTP Gin Initialization, metrics are served by the admin server when it is enabled
+	publicMetrics := metrics
+	if conf.Admin.Enabled {
+		publicMetrics = nil
+	}
 
-	// HTTP Gin Initialization
-	httpGin := config.InitHttpGin(log, middleware)
+	httpGin := config.InitHttpGin(log, conf.Server, middleware, publicMetrics, health)
 
 	// REST Handler Initialization
 	restHandler.InitRestHandler(httpGin, auth, middleware, service)
 
-	// //Scheduler Initialization
-	// scheduler = config.InitScheduler(log, conf.Scheduler)
-	// schedHandler.InitSchedulerHandler(log, scheduler, service, conf.Scheduler.SchedulerJobs)
+	// Scheduler Initialization, nil when disabled
+	scheduler := config.InitScheduler(log, conf.Scheduler, metrics)
+	schedHandler.InitSchedulerHandler(log, scheduler, service, conf.Scheduler.SchedulerJobs)
+	app.Register(config.SchedulerHook(scheduler))
+
+	// Config Reload, on SIGHUP or from the admin server
+	reloader := config.InitReloader(log, reloadConfig(log, conf, configPath, configProfile, configFlags,
+		subscribe(func(c *Config) { config.ReloadLogger(log, c.Logger) }, "logger.level"),
+		subscribe(func(c *Config) { middleware.Reload(c.Middleware) }, "middleware."),
+		subscribe(func(c *Config) { repository.User.SetCacheTTL(c.Redis.CacheTTL) }, "redis.cache_ttl"),
+		subscribe(func(c *Config) { config.SetAdminToken(c.Admin.Token) }, "admin.token", "admin.token_file"),
+		subscribe(func(c *Config) { config.RotateDBCredentials(log, sql0, c.Postgres) }, "postgres.password"),
+		subscribe(func(c *Config) { config.SetRedisPassword(c.Redis.Password) }, "redis.password"),
+		// the key files can be rotated in place, the config does not change then
+		subscribeAlways(func(c *Config) {
+			if err := auth.ReloadKeys(c.Auth); err != nil {
+				log.Error().Err(err).Msg("Failed to reload auth keys")
+			}
+		}, "auth.private_key_pem"),
+		subscribe(func(c *Config) {
+			if scheduler != nil && c.Scheduler.SchedulerJobs.UserGeneratorJob.Enabled {
+				if err := scheduler.Reschedule("UserGeneratorJob", c.Scheduler.SchedulerJobs.UserGeneratorJob.Cron); err != nil {
+					log.Error().Err(err).Msg("Failed to reschedule UserGeneratorJob")
+				}
+			}
+		}, "scheduler.jobs.user_generator.cron"),
+	))
+
+	app.Register(config.ReloadHook(reloader))
+
+	// Admin Gin Initialization, nil when the admin server is disabled
+	adminGin := config.InitAdminGin(log, conf.Admin, middleware, metrics, health)
+
+	// Admin Handler Initialization
+	adminHandler.InitAdminHandler(adminGin, log, middleware, service, scheduler, reloader)
 
 	// HTTP Server Initialization
 	httpServer := config.InitHttpServer(log, conf.Server, httpGin)
 
-	// App Initialization
-	app = config.InitGrace(log, httpServer)
-}
-
-func main() {
-	defer func() {
-		if redis0 != nil {
-			redis0.Close()
-		}
+	// HTTP/3 Server Initialization, nil when disabled
+	http3Server := config.InitHttp3Server(log, conf.Server, httpServer)
 
-		if redis1 != nil {
-			redis1.Close()
-		}
+	app.Register(config.HTTPServerHook(log, "http", httpServer))
+	if http3Server != nil {
+		app.Register(config.HTTP3ServerHook(log, "http3", http3Server))
+	}
 
-		if redis2 != nil {
-			redis2.Close()
-		}
+	// Admin Server Initialization
+	if adminServer := config.InitAdminServer(log, conf.Admin, adminGin); adminServer != nil {
+		app.Register(config.HTTPServerHook(log, "admin", adminServer))
+	}
+}
 
-		if sql0 != nil {
-			sql0.Close()
-		}
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
 
-		if scheduler != nil {
-			scheduler.Stop()
-		}
-	}()
+func main() {
+	setup()
 
-	app.Serve()
+	if err := app.Serve(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"learngolang/src/config"
+	exception "learngolang/src/errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const secretsUsage = `usage:
+  secrets keygen <key_file>
+  secrets encrypt <key_file> <plain_file> <encrypted_file>
+  secrets decrypt <key_file> <encrypted_file>`
+
+const debugUsage = `usage:
+  debug sign <method> <path> [ttl]`
+
+const messagesUsage = `usage:
+  messages lint <catalog_dir>`
+
+// runDebugCommand prints an X-Debug-Error value for the requests to method
+// and path, signed with middleware.debug_errors.signing_key and valid for ttl.
+func runDebugCommand(conf *Config, args []string) error {
+	if len(args) < 3 || len(args) > 4 || args[0] != "sign" {
+		return errors.New(debugUsage)
+	}
+
+	opt := conf.Middleware.DebugErrors
+	if opt.SigningKey == "" {
+		return errors.New("middleware.debug_errors.signing_key is not set")
+	}
+
+	maxTTL := opt.MaxTTL
+	if maxTTL <= 0 {
+		maxTTL = config.DefaultDebugErrorMaxTTL
+	}
+
+	ttl := maxTTL
+	if len(args) == 4 {
+		parsed, err := time.ParseDuration(args[3])
+		if err != nil {
+			return err
+		}
+
+		ttl = parsed
+	}
+
+	if ttl <= 0 || ttl > maxTTL {
+		return fmt.Errorf("ttl must be between 0s and middleware.debug_errors.max_ttl (%s), got %s", maxTTL, ttl)
+	}
+
+	fmt.Println(config.SignDebugError(opt.SigningKey, strings.ToUpper(args[1]), args[2], time.Now().Add(ttl)))
+
+	return nil
+}
+
+// runMessagesCommand checks that every error code has a message in every
+// catalog of the directory.
+func runMessagesCommand(args []string) error {
+	if len(args) != 2 || args[0] != "lint" {
+		return errors.New(messagesUsage)
+	}
+
+	locales, err := exception.ReadCatalogs(args[1])
+	if err != nil {
+		return err
+	}
+
+	if problems := exception.LintCatalogs(locales); len(problems) > 0 {
+		return fmt.Errorf("incomplete message catalogs:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	fmt.Printf("%d catalogs cover every error code\n", len(locales))
+
+	return nil
+}
+
+// runSecretsCommand manages the encrypted secrets file, the plain file is a
+// yaml map of secret name to value.
+func runSecretsCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(secretsUsage)
+	}
+
+	switch {
+	case args[0] == "keygen" && len(args) == 2:
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+
+		return os.WriteFile(args[1], []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600)
+	case args[0] == "encrypt" && len(args) == 4:
+		key, err := config.ReadSecretKey(args[1])
+		if err != nil {
+			return err
+		}
+
+		plain, err := os.ReadFile(args[2])
+		if err != nil {
+			return err
+		}
+
+		encrypted, err := config.EncryptSecrets(key, plain)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(args[3], encrypted, 0o600)
+	case args[0] == "decrypt" && len(args) == 3:
+		key, err := config.ReadSecretKey(args[1])
+		if err != nil {
+			return err
+		}
+
+		encrypted, err := os.ReadFile(args[2])
+		if err != nil {
+			return err
+		}
+
+		plain, err := config.DecryptSecrets(key, encrypted)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(plain)
+
+		return err
+	default:
+		return errors.New(secretsUsage)
+	}
+}
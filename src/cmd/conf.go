@@ -1,95 +1,199 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"learngolang/src/config"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/goccy/go-yaml"
 )
 
+const (
+	defaultConfigPath string = "config.yaml"
+	envConfigPath     string = "APP_CONFIG"
+	envConfigProfile  string = "APP_PROFILE"
+)
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
 type Config struct {
-	Server    config.ServerOptions   `yaml:"server"`
-	Logger    config.LoggerOptions   `yaml:"logger"`
-	Postgres  config.DatabaseOptions `yaml:"postgres"`
-	MySQL     config.DatabaseOptions `yaml:"mysql"`
-	Redis     config.RedisOptions    `yaml:"redis"`
-	Queries   config.QueriesOptions  `yaml:"queries"`
-	Auth      config.AuthOptions     `yaml:"auth"`
-	Scheduler SchedulerConfig        `yaml:"scheduler"`
+	Server     config.ServerOptions     `yaml:"server"`
+	Logger     config.LoggerOptions     `yaml:"logger"`
+	Postgres   config.DatabaseOptions   `yaml:"postgres"`
+	MySQL      config.DatabaseOptions   `yaml:"mysql"`
+	Redis      config.RedisOptions      `yaml:"redis"`
+	Queries    config.QueriesOptions    `yaml:"queries"`
+	Messages   config.MessagesOptions   `yaml:"messages"`
+	Auth       config.AuthOptions       `yaml:"auth"`
+	Scheduler  config.SchedulerOptions  `yaml:"scheduler"`
+	Middleware config.MiddlewareOptions `yaml:"middleware"`
+	Metrics    config.MetricsOptions    `yaml:"metrics"`
+	Tracing    config.TracingOptions    `yaml:"tracing"`
+	Health     config.HealthOptions     `yaml:"health"`
+	Admin      config.AdminOptions      `yaml:"admin"`
+	Secrets    config.SecretsOptions    `yaml:"secrets"`
 }
 
-type SchedulerConfig struct {
-	Enabled bool                `yaml:"enabled"`
-	Jobs    SchedulerJobsConfig `yaml:"jobs"`
-}
+// InitConfig loads the config file, deep-merges the overlay of the profile on
+// top of it (config.production.yaml for the production profile), then applies
+// the APP_* env vars and the flags. Secret references are resolved last, with
+// the providers of the secrets section and the given ones.
+func InitConfig(path string, profile string, flags configFlags, providers ...config.SecretProvider) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(envConfigPath)
+	}
 
-type SchedulerJobsConfig struct {
-	UserGenerator UserGeneratorJobConfig `yaml:"user_generator"`
-}
+	if path == "" {
+		path = defaultConfigPath
+	}
 
-type UserGeneratorJobConfig struct {
-	Enabled   bool   `yaml:"enabled"`
-	Cron      string `yaml:"cron"`
-	BatchSize int    `yaml:"batch_size"`
-	MinAge    int    `yaml:"min_age"`
-	MaxAge    int    `yaml:"max_age"`
-}
+	if profile == "" {
+		profile = os.Getenv(envConfigProfile)
+	}
 
-func InitConfig() (*Config, error) {
-	cfgPath := "config.yaml"
+	merged, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile != "" {
+		ext := filepath.Ext(path)
+		overlay, err := readConfigFile(fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), profile, ext))
+		if err != nil {
+			return nil, err
+		}
 
-	data, err := os.ReadFile(cfgPath)
+		merged = mergeConfig(merged, overlay)
+	}
+
+	data, err := yaml.Marshal(merged)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println("masuk init conf : ", err)
-	var cfg Config
+	cfg := defaultConfig()
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 
-	overrideWithEnv(&cfg)
+	if err := applyOverrides(&cfg, flags); err != nil {
+		return nil, err
+	}
+
+	if err := resolveSecrets(context.Background(), &cfg, providers...); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
-func overrideWithEnv(cfg *Config) {
-	if val := os.Getenv("SERVER_PORT"); val != "" {
-		cfg.Server.Port = parseInt(val, cfg.Server.Port)
+// defaultConfig holds the values kept when the file leaves them out, e.g. the
+// security headers the server always sent, a file opts out with enabled: false.
+func defaultConfig() Config {
+	return Config{
+		Middleware: config.MiddlewareOptions{
+			CORS:            config.CORSOptions{Enabled: true, AllowedOrigins: []string{"*"}},
+			SecurityHeaders: config.SecurityHeadersOptions{Enabled: true},
+		},
 	}
+}
 
-	if val := os.Getenv("LOG_LEVEL"); val != "" {
-		cfg.Logger.Level = val
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	if val := os.Getenv("POSTGRES_HOST"); val != "" {
-		cfg.Postgres.Host = val
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
-	if val := os.Getenv("POSTGRES_PORT"); val != "" {
-		cfg.Postgres.Port = parseInt(val, cfg.Postgres.Port)
+	// interpolated after parsing, so a value can neither change the document
+	// nor fail on a variable mentioned in a comment
+	missing := make([]string, 0)
+	for key, value := range values {
+		values[key] = interpolateValue(value, &missing)
 	}
 
-	if val := os.Getenv("POSTGRES_USER"); val != "" {
-		cfg.Postgres.User = val
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: environment variables not set: %s", path, strings.Join(missing, ", "))
 	}
 
-	if val := os.Getenv("POSTGRES_PASSWORD"); val != "" {
-		cfg.Postgres.Password = val
+	return values, nil
+}
+
+// mergeConfig merges overlay into base, nested maps are merged key by key and
+// any other value, lists included, replaces the base one.
+func mergeConfig(base map[string]any, overlay map[string]any) map[string]any {
+	for key, value := range overlay {
+		baseMap, baseOk := base[key].(map[string]any)
+		overlayMap, overlayOk := value.(map[string]any)
+		if baseOk && overlayOk {
+			base[key] = mergeConfig(baseMap, overlayMap)
+			continue
+		}
+
+		base[key] = value
 	}
 
-	if val := os.Getenv("POSTGRES_DB_NAME"); val != "" {
-		cfg.Postgres.DBName = val
+	return base
+}
+
+// interpolateValue replaces ${VAR} and ${VAR:-default} in the string scalars
+// of value, a variable that is unset without default is added to missing.
+func interpolateValue(value any, missing *[]string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = interpolateValue(item, missing)
+		}
+
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = interpolateValue(item, missing)
+		}
+
+		return v
+	case string:
+		return interpolateString(v, missing)
+	default:
+		return value
 	}
 }
 
-func parseInt(s string, defaultVal int) int {
-	var val int
-	if _, err := fmt.Sscanf(s, "%d", &val); err == nil {
-		return val
+func interpolateString(text string, missing *[]string) any {
+	expanded := envPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := envPattern.FindStringSubmatch(match)
+		if val, ok := os.LookupEnv(groups[1]); ok {
+			return val
+		}
+
+		if groups[2] != "" {
+			return groups[3]
+		}
+
+		*missing = append(*missing, groups[1])
+
+		return match
+	})
+
+	// a value made of a single variable keeps the type of its content, e.g.
+	// port: ${PORT}, anything but a bool or a number stays a string
+	if expanded != text && envPattern.FindString(text) == text {
+		var scalar any
+		if err := yaml.Unmarshal([]byte(expanded), &scalar); err == nil {
+			switch scalar.(type) {
+			case bool, int, int64, uint64, float64:
+				return scalar
+			}
+		}
 	}
 
-	return defaultVal
+	return expanded
 }
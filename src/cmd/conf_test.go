@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMergeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    map[string]any
+		overlay map[string]any
+		want    map[string]any
+	}{
+		{
+			name:    "nested maps merge key by key",
+			base:    map[string]any{"redis": map[string]any{"address": "localhost:6379", "db": 0}},
+			overlay: map[string]any{"redis": map[string]any{"address": "redis:6379"}},
+			want:    map[string]any{"redis": map[string]any{"address": "redis:6379", "db": 0}},
+		},
+		{
+			name:    "lists are replaced",
+			base:    map[string]any{"origins": []any{"a", "b"}},
+			overlay: map[string]any{"origins": []any{"c"}},
+			want:    map[string]any{"origins": []any{"c"}},
+		},
+		{
+			name:    "scalar replaces a map",
+			base:    map[string]any{"tracing": map[string]any{"enabled": true}},
+			overlay: map[string]any{"tracing": "off"},
+			want:    map[string]any{"tracing": "off"},
+		},
+		{
+			name:    "new keys are added",
+			base:    map[string]any{"server": map[string]any{"port": 8080}},
+			overlay: map[string]any{"metrics": map[string]any{"enabled": true}},
+			want:    map[string]any{"server": map[string]any{"port": 8080}, "metrics": map[string]any{"enabled": true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeConfig(tt.base, tt.overlay); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateString(t *testing.T) {
+	t.Setenv("APP_TEST_HOST", "db.internal")
+	t.Setenv("APP_TEST_PORT", "5433")
+	t.Setenv("APP_TEST_FLAG", "true")
+	t.Setenv("APP_TEST_EMPTY", "")
+
+	tests := []struct {
+		name        string
+		text        string
+		want        any
+		wantMissing []string
+	}{
+		{name: "no variable", text: "localhost", want: "localhost"},
+		{name: "variable", text: "${APP_TEST_HOST}", want: "db.internal"},
+		{name: "embedded variables", text: "${APP_TEST_HOST}:${APP_TEST_PORT}", want: "db.internal:5433"},
+		{name: "single number keeps its type", text: "${APP_TEST_PORT}", want: uint64(5433)},
+		{name: "single bool keeps its type", text: "${APP_TEST_FLAG}", want: true},
+		{name: "default", text: "${APP_TEST_UNSET:-fallback}", want: "fallback"},
+		{name: "set to empty skips the default", text: "${APP_TEST_EMPTY:-fallback}", want: ""},
+		{name: "missing", text: "${APP_TEST_UNSET}", want: "${APP_TEST_UNSET}", wantMissing: []string{"APP_TEST_UNSET"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing := make([]string, 0)
+
+			got := interpolateString(tt.text, &missing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interpolateString() = %#v, want %#v", got, tt.want)
+			}
+
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("interpolateString() missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestInitConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, path, `
+server:
+  port: ${APP_TEST_PORT:-8080}
+  mode: debug
+redis:
+  address: localhost:6379
+middleware:
+  cors:
+    allowed_origins: ["https://a.example", "https://b.example"]
+`)
+	writeFile(t, filepath.Join(dir, "config.production.yaml"), `
+server:
+  mode: release
+redis:
+  address: ${APP_TEST_REDIS}
+middleware:
+  cors:
+    allowed_origins: ["https://app.example"]
+`)
+
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr bool
+	}{
+		{
+			name: "no profile",
+			check: func(c *Config) bool {
+				return c.Server.Port == 8080 && c.Server.Mode == "debug" && c.Redis.Address == "localhost:6379"
+			},
+		},
+		{
+			name:    "production overlay",
+			profile: "production",
+			env:     map[string]string{"APP_TEST_REDIS": "redis:6379", "APP_TEST_PORT": "9000"},
+			check: func(c *Config) bool {
+				return c.Server.Port == 9000 && c.Server.Mode == "release" && c.Redis.Address == "redis:6379" &&
+					slices.Equal(c.Middleware.CORS.AllowedOrigins, []string{"https://app.example"})
+			},
+		},
+		{
+			name:    "overlay variable not set",
+			profile: "production",
+			wantErr: true,
+		},
+		{
+			name:    "overlay file missing",
+			profile: "staging",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, val := range tt.env {
+				t.Setenv(key, val)
+			}
+
+			cfg, err := InitConfig(path, tt.profile, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InitConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !tt.check(cfg) {
+				t.Errorf("InitConfig() = %+v", cfg)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
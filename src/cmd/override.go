@@ -0,0 +1,269 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	envPrefix     string = "APP_"
+	redactedValue string = "******"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// legacyEnv keeps the variables read before the APP_ prefix existed working.
+var legacyEnv = map[string]string{
+	"SERVER_PORT":       "server.port",
+	"LOG_LEVEL":         "logger.level",
+	"POSTGRES_HOST":     "postgres.host",
+	"POSTGRES_PORT":     "postgres.port",
+	"POSTGRES_USER":     "postgres.user",
+	"POSTGRES_PASSWORD": "postgres.password",
+	"POSTGRES_DB_NAME":  "postgres.dbname",
+}
+
+// configField is a leaf of Config addressed by its yaml path, e.g. redis.address.
+type configField struct {
+	key    string
+	secret bool
+	value  reflect.Value
+	// overridable is false for the types setField cannot parse, e.g. lists of
+	// sections, they can only be set in the file
+	overridable bool
+}
+
+// configFlags holds the raw values of the config flags set on the command line.
+type configFlags map[string]string
+
+type configFlag struct {
+	key    string
+	flags  configFlags
+	isBool bool
+}
+
+func (f *configFlag) String() string {
+	return ""
+}
+
+// IsBoolFlag lets a bool field be set by the bare flag, e.g. -logger.enabled.
+func (f *configFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+func (f *configFlag) Set(value string) error {
+	f.flags[f.key] = value
+	return nil
+}
+
+// bindConfigFlags registers a flag for every config field, e.g. -redis.address.
+// The values are applied by InitConfig on top of the file and the environment.
+func bindConfigFlags(fs *flag.FlagSet) configFlags {
+	flags := make(configFlags)
+
+	for _, field := range configFields(reflect.ValueOf(&Config{}).Elem(), "") {
+		if !field.overridable {
+			continue
+		}
+
+		usage := fmt.Sprintf("overrides %s (env %s)", field.key, envName(field.key))
+		fs.Var(&configFlag{
+			key:    field.key,
+			flags:  flags,
+			isBool: field.value.Kind() == reflect.Bool,
+		}, field.key, usage)
+	}
+
+	return flags
+}
+
+// applyOverrides sets the config from APP_* variables, then from the flags,
+// e.g. APP_AUTH_EXPIRED_TOKEN=15m or -auth.expired_token=15m.
+func applyOverrides(cfg *Config, flags configFlags) error {
+	fields := configFields(reflect.ValueOf(cfg).Elem(), "")
+	byKey := make(map[string]configField, len(fields))
+	for _, field := range fields {
+		byKey[field.key] = field
+	}
+
+	for env, key := range legacyEnv {
+		if val, ok := os.LookupEnv(env); ok && val != "" {
+			if err := setField(byKey[key], val); err != nil {
+				return fmt.Errorf("env %s: %w", env, err)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if !field.overridable {
+			continue
+		}
+
+		if val, ok := os.LookupEnv(envName(field.key)); ok {
+			if err := setField(field, val); err != nil {
+				return fmt.Errorf("env %s: %w", envName(field.key), err)
+			}
+		}
+	}
+
+	for key, val := range flags {
+		if err := setField(byKey[key], val); err != nil {
+			return fmt.Errorf("flag -%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// printConfig writes the effective config as yaml, fields tagged secret are redacted.
+func printConfig(cfg *Config) error {
+	redacted := *cfg
+	for _, field := range configFields(reflect.ValueOf(&redacted).Elem(), "") {
+		if !field.secret {
+			continue
+		}
+
+		switch {
+		case field.value.Kind() == reflect.String && field.value.String() != "":
+			field.value.SetString(redactedValue)
+		case field.value.Kind() == reflect.Slice && field.value.Len() > 0:
+			// a new slice, the copy of the config shares the backing array
+			items := make([]string, field.value.Len())
+			for i := range items {
+				items[i] = redactedValue
+			}
+
+			field.value.Set(reflect.ValueOf(items))
+		}
+	}
+
+	out, err := yaml.MarshalWithOptions(redacted, yaml.UseLiteralStyleIfMultiline(true))
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(out)
+
+	return err
+}
+
+func configFields(v reflect.Value, prefix string) []configField {
+	fields := make([]configField, 0)
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			fields = append(fields, configFields(fv, key)...)
+			continue
+		}
+
+		fields = append(fields, configField{
+			key:         key,
+			secret:      sf.Tag.Get("secret") == "true",
+			value:       fv,
+			overridable: isOverridable(fv.Type()),
+		})
+	}
+
+	return fields
+}
+
+// isOverridable tells if setField can parse a value of type t.
+func isOverridable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func setField(field configField, raw string) error {
+	v := field.value
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case v.CanUint():
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case v.CanFloat():
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(n)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		// lists are comma separated, an empty value clears the list
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s for %s", v.Type(), field.key)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSetField(t *testing.T) {
+	type sample struct {
+		Timeout time.Duration
+		Name    string
+		Enabled bool
+		Count   int
+		Small   int8
+		Size    uint32
+		Ratio   float64
+		Items   []string
+		Labels  map[string]string
+	}
+
+	tests := []struct {
+		name    string
+		field   string
+		raw     string
+		want    any
+		wantErr bool
+	}{
+		{name: "duration", field: "Timeout", raw: "15m", want: 15 * time.Minute},
+		{name: "invalid duration", field: "Timeout", raw: "15", wantErr: true},
+		{name: "string is trimmed", field: "Name", raw: " redis:6379 ", want: "redis:6379"},
+		{name: "bool", field: "Enabled", raw: "true", want: true},
+		{name: "invalid bool", field: "Enabled", raw: "yes", wantErr: true},
+		{name: "int", field: "Count", raw: "-42", want: -42},
+		{name: "int overflow", field: "Small", raw: "300", wantErr: true},
+		{name: "uint", field: "Size", raw: "8080", want: uint32(8080)},
+		{name: "negative uint", field: "Size", raw: "-1", wantErr: true},
+		{name: "float", field: "Ratio", raw: "0.25", want: 0.25},
+		{name: "comma list", field: "Items", raw: "a, b,,c", want: []string{"a", "b", "c"}},
+		{name: "empty list clears", field: "Items", raw: "", want: []string{}},
+		{name: "map is rejected", field: "Labels", raw: "a=b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sample{Items: []string{"old"}}
+			v := reflect.ValueOf(&s).Elem().FieldByName(tt.field)
+
+			err := setField(configField{key: tt.field, value: v}, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setField() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := v.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setField() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOverridable(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want bool
+	}{
+		{name: "string", typ: reflect.TypeOf(""), want: true},
+		{name: "duration", typ: durationType, want: true},
+		{name: "string list", typ: reflect.TypeOf([]string{}), want: true},
+		{name: "map", typ: reflect.TypeOf(map[string]string{}), want: false},
+		{name: "list of sections", typ: reflect.TypeOf([]struct{ Name string }{}), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOverridable(tt.typ); got != tt.want {
+				t.Errorf("isOverridable(%s) = %v, want %v", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindConfigFlags(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags := bindConfigFlags(fs)
+
+	if fs.Lookup("logger.sinks") != nil {
+		t.Error("bindConfigFlags() registered a flag for a list of sections")
+	}
+
+	err := fs.Parse([]string{"-logger.enabled", "-server.port=9000", "-middleware.cors.allowed_origins=https://a.example,https://b.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := configFlags{
+		"logger.enabled":                  "true",
+		"server.port":                     "9000",
+		"middleware.cors.allowed_origins": "https://a.example,https://b.example",
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("bindConfigFlags() = %v, want %v", flags, want)
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		flags   configFlags
+		check   func(c *Config) bool
+		wantErr bool
+	}{
+		{
+			name:  "legacy env",
+			env:   map[string]string{"SERVER_PORT": "8081"},
+			check: func(c *Config) bool { return c.Server.Port == 8081 },
+		},
+		{
+			name:  "APP env wins over legacy env",
+			env:   map[string]string{"SERVER_PORT": "8081", "APP_SERVER_PORT": "8082"},
+			check: func(c *Config) bool { return c.Server.Port == 8082 },
+		},
+		{
+			name:  "flag wins over env",
+			env:   map[string]string{"APP_SERVER_PORT": "8082"},
+			flags: configFlags{"server.port": "8083"},
+			check: func(c *Config) bool { return c.Server.Port == 8083 },
+		},
+		{
+			name: "duration and list",
+			env:  map[string]string{"APP_AUTH_EXPIRED_TOKEN": "15m", "APP_MIDDLEWARE_CORS_ALLOWED_ORIGINS": "https://a.example"},
+			check: func(c *Config) bool {
+				return c.Auth.ExpiredToken == 15*time.Minute && slices.Equal(c.Middleware.CORS.AllowedOrigins, []string{"https://a.example"})
+			},
+		},
+		{
+			name:    "invalid env",
+			env:     map[string]string{"APP_SERVER_PORT": "http"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid flag",
+			flags:   configFlags{"logger.enabled": "maybe"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, val := range tt.env {
+				t.Setenv(key, val)
+			}
+
+			cfg := &Config{}
+			err := applyOverrides(cfg, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !tt.check(cfg) {
+				t.Errorf("applyOverrides() = %+v", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"learngolang/src/config"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// reloadableKeys are applied at runtime by a subscriber, a key ending with a
+// dot covers the whole section. A change to any other key rejects the reload.
+var reloadableKeys = []string{
+	"logger.level",
+	"middleware.",
+	"redis.cache_ttl",
+	"scheduler.jobs.user_generator.cron",
+	"admin.token",
+	"admin.token_file",
+}
+
+// configSubscriber applies the reloaded config to a running component, the
+// config is validated before any subscriber is called. It only runs when one
+// of its keys changed or was rotated, so a reload leaves the runtime changes
+// of the other settings alone, e.g. a log level set from the admin server.
+type configSubscriber struct {
+	keys  []string
+	apply func(cfg *Config)
+	// always runs the subscriber on every reload, e.g. to read files rotated
+	// in place, the keys only tell which secrets it applies
+	always bool
+}
+
+func subscribe(apply func(cfg *Config), keys ...string) configSubscriber {
+	return configSubscriber{keys: keys, apply: apply}
+}
+
+func subscribeAlways(apply func(cfg *Config), keys ...string) configSubscriber {
+	return configSubscriber{keys: keys, apply: apply, always: true}
+}
+
+func (s configSubscriber) matches(changed []string) bool {
+	if s.always {
+		return true
+	}
+
+	for _, key := range changed {
+		if matchesKey(key, s.keys) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reloadConfig loads the config the same way as on start, flags included, and
+// pushes it to the subscribers when only reloadable keys changed.
+func reloadConfig(log zerolog.Logger, current *Config, path string, profile string, flags configFlags, subscribers ...configSubscriber) config.ReloadFunc {
+	return func(ctx context.Context) ([]string, error) {
+		next, err := InitConfig(path, profile, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := next.Validate(); err != nil {
+			return nil, err
+		}
+
+		changed, rejected, rotated := diffConfig(current, next)
+		if len(rejected) > 0 {
+			return nil, fmt.Errorf("cannot change at runtime, restart required: %s", strings.Join(rejected, ", "))
+		}
+
+		// a rotated secret never blocks the reload, the ones without a
+		// subscriber are only used by the next process
+		if pending := unsubscribed(rotated, subscribers); len(pending) > 0 {
+			log.Warn().Strs("keys", pending).Msg("Secrets rotated, they apply after a restart")
+		}
+
+		updated := slices.Concat(changed, rotated)
+		for _, subscriber := range subscribers {
+			if subscriber.matches(updated) {
+				subscriber.apply(next)
+			}
+		}
+
+		current = next
+
+		return changed, nil
+	}
+}
+
+func diffConfig(current *Config, next *Config) (changed []string, rejected []string, rotated []string) {
+	currentFields := configFields(reflect.ValueOf(current).Elem(), "")
+	nextFields := configFields(reflect.ValueOf(next).Elem(), "")
+
+	for i, field := range currentFields {
+		if reflect.DeepEqual(field.value.Interface(), nextFields[i].value.Interface()) {
+			continue
+		}
+
+		switch {
+		case isReloadable(field.key):
+			changed = append(changed, field.key)
+		case field.secret:
+			rotated = append(rotated, field.key)
+		default:
+			rejected = append(rejected, field.key)
+		}
+	}
+
+	return changed, rejected, rotated
+}
+
+func unsubscribed(keys []string, subscribers []configSubscriber) []string {
+	pending := make([]string, 0)
+	for _, key := range keys {
+		if !slices.ContainsFunc(subscribers, func(s configSubscriber) bool { return matchesKey(key, s.keys) }) {
+			pending = append(pending, key)
+		}
+	}
+
+	return pending
+}
+
+func isReloadable(key string) bool {
+	return matchesKey(key, reloadableKeys)
+}
+
+// matchesKey tells if key is one of keys, a key ending with a dot covers the section.
+func matchesKey(key string, keys []string) bool {
+	for _, k := range keys {
+		if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		update       func(c *Config)
+		wantChanged  []string
+		wantRejected []string
+		wantRotated  []string
+	}{
+		{
+			name:   "unchanged",
+			update: func(c *Config) {},
+		},
+		{
+			name: "reloadable keys",
+			update: func(c *Config) {
+				c.Logger.Level = "debug"
+				c.Redis.CacheTTL = time.Minute
+			},
+			wantChanged: []string{"logger.level", "redis.cache_ttl"},
+		},
+		{
+			name:        "section covers its keys",
+			update:      func(c *Config) { c.Middleware.CORS.AllowedOrigins = []string{"https://app.example"} },
+			wantChanged: []string{"middleware.cors.allowed_origins"},
+		},
+		{
+			name:        "reloadable secret is changed",
+			update:      func(c *Config) { c.Admin.Token = "next" },
+			wantChanged: []string{"admin.token"},
+		},
+		{
+			name: "secret is rotated",
+			update: func(c *Config) {
+				c.Postgres.Password = "next"
+				c.Auth.PrivateKeyPEM = "next"
+			},
+			wantRotated: []string{"postgres.password", "auth.private_key_pem"},
+		},
+		{
+			name: "other keys are rejected",
+			update: func(c *Config) {
+				c.Server.Port = 9000
+				c.Logger.Level = "debug"
+			},
+			wantChanged:  []string{"logger.level"},
+			wantRejected: []string{"server.port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := defaultConfig()
+			current.Logger.Level = "info"
+
+			next := defaultConfig()
+			next.Logger.Level = "info"
+			tt.update(&next)
+
+			changed, rejected, rotated := diffConfig(&current, &next)
+			if !slices.Equal(changed, tt.wantChanged) {
+				t.Errorf("diffConfig() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			if !slices.Equal(rejected, tt.wantRejected) {
+				t.Errorf("diffConfig() rejected = %v, want %v", rejected, tt.wantRejected)
+			}
+
+			if !slices.Equal(rotated, tt.wantRotated) {
+				t.Errorf("diffConfig() rotated = %v, want %v", rotated, tt.wantRotated)
+			}
+		})
+	}
+}
+
+func TestConfigSubscriberMatches(t *testing.T) {
+	apply := func(c *Config) {}
+
+	tests := []struct {
+		name       string
+		subscriber configSubscriber
+		changed    []string
+		want       bool
+	}{
+		{name: "key", subscriber: subscribe(apply, "logger.level"), changed: []string{"logger.level"}, want: true},
+		{name: "section", subscriber: subscribe(apply, "middleware."), changed: []string{"middleware.cors.enabled"}, want: true},
+		{name: "prefix is not a section", subscriber: subscribe(apply, "admin.token"), changed: []string{"admin.token_file"}, want: false},
+		{name: "other key", subscriber: subscribe(apply, "logger.level"), changed: []string{"redis.cache_ttl"}, want: false},
+		{name: "nothing changed", subscriber: subscribe(apply, "logger.level"), want: false},
+		{name: "always", subscriber: subscribeAlways(apply, "auth.private_key_pem"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscriber.matches(tt.changed); got != tt.want {
+				t.Errorf("matches(%v) = %v, want %v", tt.changed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnsubscribed(t *testing.T) {
+	subscribers := []configSubscriber{
+		subscribeAlways(func(c *Config) {}, "auth.private_key_pem"),
+	}
+
+	got := unsubscribed([]string{"auth.private_key_pem", "postgres.password"}, subscribers)
+	if want := []string{"postgres.password"}; !slices.Equal(got, want) {
+		t.Errorf("unsubscribed() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"learngolang/src/config"
+	"reflect"
+	"strings"
+)
+
+const secretFileSuffix string = "File"
+
+// resolveSecrets fills every field tagged secret. The sibling *File field wins,
+// e.g. password_file, then a reference to a provider such as file://pg_password
+// or encrypted://pg_password is resolved, any other value is used as is.
+func resolveSecrets(ctx context.Context, cfg *Config, extra ...config.SecretProvider) error {
+	providers, err := config.InitSecretProviders(cfg.Secrets)
+	if err != nil {
+		return err
+	}
+
+	byScheme := make(map[string]config.SecretProvider)
+	for _, provider := range append(providers, extra...) {
+		byScheme[provider.Scheme()] = provider
+	}
+
+	return resolveSecretFields(ctx, reflect.ValueOf(cfg).Elem(), "", byScheme)
+}
+
+func resolveSecretFields(ctx context.Context, v reflect.Value, prefix string, providers map[string]config.SecretProvider) error {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		fv := v.Field(i)
+
+		key := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if fv.Kind() == reflect.Struct {
+			if err := resolveSecretFields(ctx, fv, key, providers); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if sf.Tag.Get("secret") != "true" || fv.Kind() != reflect.String {
+			continue
+		}
+
+		if file := v.FieldByName(sf.Name + secretFileSuffix); file.IsValid() && file.String() != "" {
+			secret, err := config.ReadSecretFile(file.String())
+			if err != nil {
+				return fmt.Errorf("%s_file: %w", key, err)
+			}
+
+			fv.SetString(secret)
+			continue
+		}
+
+		scheme, name, ok := strings.Cut(fv.String(), "://")
+		if !ok {
+			continue
+		}
+
+		provider, ok := providers[scheme]
+		if !ok {
+			// only the built-in schemes are references when their provider is not configured
+			if scheme == config.SecretProviderFile || scheme == config.SecretProviderEncrypted {
+				return fmt.Errorf("%s: secret provider %q is not configured", key, scheme)
+			}
+
+			continue
+		}
+
+		secret, err := provider.GetSecret(ctx, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		fv.SetString(secret)
+	}
+
+	return nil
+}
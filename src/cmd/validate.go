@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"learngolang/src/config"
+	exception "learngolang/src/errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
+)
+
+var (
+	serverModes       = []string{"debug", "release", "test"}
+	databaseDrivers   = []string{"postgres", "mysql"}
+	redisNetworks     = []string{"", "tcp", "unix"}
+	logOutputs        = []string{"", config.LogOutputStdout, config.LogOutputStderr, config.LogOutputFile, config.LogOutputSyslog}
+	logFormats        = []string{"", config.LogFormatJSON, config.LogFormatConsole}
+	errorFormats      = []string{"", config.ErrorFormatEnvelope, config.ErrorFormatProblem}
+	debugErrorExposes = []string{"", config.DebugErrorExposeAuto, config.DebugErrorExposeAll, config.DebugErrorExposeAuthorized, config.DebugErrorExposeNone}
+	logRedactionModes = []string{"", config.LogRedactionAuto, config.LogRedactionOn, config.LogRedactionOff}
+
+	// cronParser matches the scheduler, which runs cron with a seconds field
+	cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+)
+
+// ConfigErrors lists every problem found in the config, one per line.
+type ConfigErrors []string
+
+func (e ConfigErrors) Error() string {
+	return "invalid config:\n  " + strings.Join(e, "\n  ")
+}
+
+type configValidator struct {
+	errs ConfigErrors
+}
+
+func (v *configValidator) check(ok bool, key string, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *configValidator) required(key string, value string) {
+	v.check(strings.TrimSpace(value) != "", key, "is required")
+}
+
+func (v *configValidator) port(key string, port int, optional bool) {
+	if optional && port == 0 {
+		return
+	}
+
+	v.check(port > 0 && port <= 65535, key, "must be between 1 and 65535, got %d", port)
+}
+
+func (v *configValidator) nonNegative(key string, d time.Duration) {
+	v.check(d >= 0, key, "must not be negative, got %s", d)
+}
+
+func (v *configValidator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, "must be greater than zero, got %s", d)
+}
+
+func (v *configValidator) oneOf(key string, value string, allowed []string) {
+	v.check(slices.Contains(allowed, value), key, "must be one of [%s], got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *configValidator) file(key string, path string) {
+	if strings.TrimSpace(path) == "" {
+		v.required(key, path)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		v.check(false, key, "%v", err)
+		return
+	}
+
+	v.check(!info.IsDir(), key, "%s is a directory", path)
+}
+
+// Validate reports every problem of the config at once, it runs before any
+// connection is opened so a bad config never fails deep inside an Init function.
+func (c *Config) Validate() error {
+	v := &configValidator{}
+
+	v.port("server.port", c.Server.Port, false)
+	v.nonNegative("server.write_timeout", c.Server.WriteTimeout)
+	v.nonNegative("server.read_timeout", c.Server.ReadTimeout)
+	v.nonNegative("server.idle_timeout", c.Server.IdleTimeout)
+	v.nonNegative("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.nonNegative("server.upgrade_timeout", c.Server.UpgradeTimeout)
+	v.oneOf("server.mode", c.Server.Mode, serverModes)
+	for i, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		v.check(cidrErr == nil || net.ParseIP(proxy) != nil, fmt.Sprintf("server.trusted_proxies[%d]", i), "%q is not an IP or a CIDR", proxy)
+	}
+
+	if c.Server.TLS.Enabled {
+		v.file("server.tls.cert_file", c.Server.TLS.CertFile)
+		v.file("server.tls.key_file", c.Server.TLS.KeyFile)
+		v.oneOf("server.tls.min_version", c.Server.TLS.MinVersion, []string{"", "1.2", "1.3"})
+		if c.Server.TLS.ClientCAFile != "" {
+			v.file("server.tls.client_ca_file", c.Server.TLS.ClientCAFile)
+		}
+	}
+
+	if c.Server.HTTP3.Enabled {
+		v.check(c.Server.TLS.Enabled, "server.http3.enabled", "requires server.tls.enabled")
+		v.port("server.http3.port", c.Server.HTTP3.Port, true)
+	}
+
+	if _, err := zerolog.ParseLevel(c.Logger.Level); err != nil {
+		v.check(false, "logger.level", "%v", err)
+	}
+
+	if c.Logger.Enabled {
+		sinks := c.Logger.Sinks
+		if len(sinks) == 0 {
+			sinks = []config.LogSinkOptions{{Output: c.Logger.Output, Format: c.Logger.Format, Path: c.Logger.Path}}
+		}
+
+		for i, sink := range sinks {
+			key := fmt.Sprintf("logger.sinks[%d]", i)
+			if len(c.Logger.Sinks) == 0 {
+				key = "logger"
+			}
+
+			v.oneOf(key+".output", sink.Output, logOutputs)
+			v.oneOf(key+".format", sink.Format, logFormats)
+			if sink.Output == config.LogOutputFile {
+				v.required(key+".path", sink.Path)
+			}
+
+			if _, err := zerolog.ParseLevel(sink.Level); err != nil {
+				v.check(false, key+".level", "%v", err)
+			}
+		}
+
+		v.check(c.Logger.MaxSize >= 0, "logger.max_size", "must not be negative")
+		v.check(c.Logger.MaxBackups >= 0, "logger.max_backups", "must not be negative")
+		v.check(c.Logger.MaxAge >= 0, "logger.max_age", "must not be negative")
+	}
+
+	if sampling := c.Logger.Sampling; sampling.Enabled {
+		v.positive("logger.sampling.period", sampling.Period)
+		v.check(sampling.Burst > 0 || sampling.Thereafter > 0, "logger.sampling", "burst or thereafter is required when sampling is enabled")
+	}
+
+	v.oneOf("logger.redaction.mode", c.Logger.Redaction.Mode, logRedactionModes)
+	if err := config.CompileLogRedactionPatterns(c.Logger.Redaction.Patterns); err != nil {
+		v.check(false, "logger.redaction.patterns", "%v", err)
+	}
+
+	validateDatabase(v, "postgres", c.Postgres)
+	validateDatabase(v, "mysql", c.MySQL)
+
+	if c.Redis.Enabled {
+		v.required("redis.address", c.Redis.Address)
+		v.oneOf("redis.network", c.Redis.Network, redisNetworks)
+		v.nonNegative("redis.cache_ttl", c.Redis.CacheTTL)
+		v.nonNegative("redis.dial_timeout", c.Redis.DialTimeout)
+		v.nonNegative("redis.read_timeout", c.Redis.ReadTimeout)
+		v.nonNegative("redis.write_timeout", c.Redis.WriteTimeout)
+		v.check(c.Redis.PoolSize >= 0, "redis.pool_size", "must not be negative")
+		v.check(c.Redis.MinRetryBackoff <= c.Redis.MaxRetryBackoff || c.Redis.MaxRetryBackoff == 0,
+			"redis.min_retry_backoff", "must not be greater than redis.max_retry_backoff")
+	}
+
+	if _, err := config.ReadQueries(c.Queries); err != nil {
+		v.check(false, "queries.path", "%v", err)
+	}
+
+	v.required("messages.default_language", c.Messages.DefaultLanguage)
+	if locales, err := exception.ReadCatalogs(c.Messages.Path); err != nil {
+		v.check(false, "messages.path", "%v", err)
+	} else {
+		_, ok := locales[strings.ToLower(c.Messages.DefaultLanguage)]
+		v.check(ok || c.Messages.DefaultLanguage == "", "messages.default_language", "no catalog for %q", c.Messages.DefaultLanguage)
+		for _, problem := range exception.LintCatalogs(locales) {
+			v.check(false, "messages.path", "%s", problem)
+		}
+	}
+
+	if c.Auth.PrivateKeyPEM == "" {
+		v.file("auth.private_key", c.Auth.PrivateKey)
+	}
+
+	v.file("auth.public_key", c.Auth.PublicKey)
+	v.positive("auth.expired_token", c.Auth.ExpiredToken)
+	v.positive("auth.expired_refresh_token", c.Auth.ExpiredRefreshToken)
+
+	if job := c.Scheduler.SchedulerJobs.UserGeneratorJob; c.Scheduler.Enabled && job.Enabled {
+		if _, err := cronParser.Parse(job.Cron); err != nil {
+			v.check(false, "scheduler.jobs.user_generator.cron", "%v", err)
+		}
+
+		v.check(job.BatchSize > 0, "scheduler.jobs.user_generator.batch_size", "must be greater than zero, got %d", job.BatchSize)
+		v.check(job.MinAge >= 0, "scheduler.jobs.user_generator.min_age", "must not be negative, got %d", job.MinAge)
+		v.check(job.MinAge <= job.MaxAge, "scheduler.jobs.user_generator.min_age", "must not be greater than max_age (%d > %d)", job.MinAge, job.MaxAge)
+	}
+
+	if cors := c.Middleware.CORS; cors.Enabled {
+		v.check(len(cors.AllowedOrigins) > 0, "middleware.cors.allowed_origins", "is required when cors is enabled")
+		v.check(!cors.AllowCredentials || !slices.Contains(cors.AllowedOrigins, "*"),
+			"middleware.cors.allow_credentials", "cannot be used with the * origin")
+		v.nonNegative("middleware.cors.max_age", cors.MaxAge)
+	}
+
+	if idem := c.Middleware.Idempotency; idem.Enabled {
+		v.nonNegative("middleware.idempotency.ttl", idem.TTL)
+		v.nonNegative("middleware.idempotency.lock_ttl", idem.LockTTL)
+		v.check(idem.MaxKeyLength >= 0, "middleware.idempotency.max_key_length", "must not be negative")
+	}
+
+	v.oneOf("middleware.error_format", c.Middleware.ErrorFormat, errorFormats)
+	v.oneOf("middleware.debug_errors.expose", c.Middleware.DebugErrors.Expose, debugErrorExposes)
+	v.nonNegative("middleware.debug_errors.max_ttl", c.Middleware.DebugErrors.MaxTTL)
+
+	accessLog := c.Middleware.AccessLog
+	v.nonNegative("middleware.access_log.slow_threshold", accessLog.SlowThreshold)
+	v.check(accessLog.BodySampleRate >= 0 && accessLog.BodySampleRate <= 1, "middleware.access_log.body_sample_rate", "must be between 0 and 1")
+	v.check(accessLog.MaxBodySize >= 0, "middleware.access_log.max_body_size", "must not be negative")
+
+	if tracing := c.Tracing; tracing.Enabled {
+		v.check(config.IsSpanExporter(tracing.Exporter), "tracing.exporter", "unknown exporter %q", tracing.Exporter)
+		v.check(tracing.SampleRatio >= 0 && tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+		if tracing.Exporter == config.TracingExporterFile {
+			v.required("tracing.path", tracing.Path)
+		}
+
+		for i, header := range tracing.OTLP.Headers {
+			v.check(strings.Contains(header, "="), fmt.Sprintf("tracing.otlp.headers[%d]", i), "must be key=value")
+		}
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Path != "" {
+		v.check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path", "must start with /")
+	}
+
+	v.nonNegative("health.timeout", c.Health.Timeout)
+	v.nonNegative("health.shutdown_delay", c.Health.ShutdownDelay)
+
+	if c.Admin.Enabled {
+		v.port("admin.port", c.Admin.Port, true)
+		v.check(c.Admin.Port == 0 || c.Admin.Port != c.Server.Port, "admin.port", "must differ from server.port")
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+func validateDatabase(v *configValidator, key string, opt config.DatabaseOptions) {
+	if !opt.Enabled {
+		return
+	}
+
+	v.oneOf(key+".driver", opt.Driver, databaseDrivers)
+	v.required(key+".host", opt.Host)
+	v.port(key+".port", opt.Port, false)
+	v.required(key+".user", opt.User)
+	v.required(key+".dbname", opt.DBName)
+	v.check(opt.MaxOpenConns >= 0, key+".max_open_conns", "must not be negative")
+	v.check(opt.MaxIdleConns >= 0, key+".max_idle_conns", "must not be negative")
+	v.nonNegative(key+".conn_max_lifetime", opt.ConnMaxLifetime)
+	v.nonNegative(key+".conn_max_idle_time", opt.ConnMaxIdleTime)
+}
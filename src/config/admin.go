@@ -0,0 +1,148 @@
+package config
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultAdminHost string = "127.0.0.1"
+	defaultAdminPort int    = 9090
+)
+
+var (
+	onceAdmin = &sync.Once{}
+
+	// adminToken is swapped on config reload, so a rotated token needs no restart
+	adminToken atomic.Pointer[string]
+)
+
+type AdminOptions struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	// Token is required as bearer token on every admin request when set
+	Token     string `yaml:"token" secret:"true"`
+	TokenFile string `yaml:"token_file"`
+	Pprof     bool   `yaml:"pprof"`
+}
+
+// InitAdminGin builds the engine of the admin listener, it hosts pprof, the
+// metrics and the detailed readiness, the operational endpoints are
+// registered by the admin handler.
+func InitAdminGin(log zerolog.Logger, opt AdminOptions, middleware Middleware, metrics *Metrics, health *Health) *gin.Engine {
+	var router *gin.Engine
+
+	if !opt.Enabled {
+		return nil
+	}
+
+	onceAdmin.Do(func() {
+		router = gin.New()
+		router.Use(middleware.Handler())
+		router.Use(middleware.Recovery())
+		SetAdminToken(opt.Token)
+		router.Use(adminAuth(middleware))
+
+		if metrics != nil {
+			router.GET(metrics.Path(), gin.WrapH(metrics.Handler()))
+		}
+
+		router.GET("/readyz", health.ReadyzDetail)
+
+		if opt.Pprof {
+			debug := router.Group("/debug/pprof")
+			debug.GET("/", gin.WrapF(pprof.Index))
+			debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+			debug.GET("/profile", gin.WrapF(pprof.Profile))
+			debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+			debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+			debug.GET("/trace", gin.WrapF(pprof.Trace))
+			debug.GET("/:profile", func(c *gin.Context) {
+				pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+			})
+		}
+
+		if opt.Token == "" && opt.Host != defaultAdminHost && opt.Host != "localhost" {
+			log.Warn().Str("host", opt.Host).Msg("Admin server is exposed without token")
+		}
+	})
+
+	return router
+}
+
+func InitAdminServer(log zerolog.Logger, opt AdminOptions, router *gin.Engine) *http.Server {
+	if !opt.Enabled || router == nil {
+		return nil
+	}
+
+	host := opt.Host
+	if host == "" {
+		host = defaultAdminHost
+	}
+
+	port := opt.Port
+	if port == 0 {
+		port = defaultAdminPort
+	}
+
+	addr := fmt.Sprintf("%s:%d", host, port)
+	log.Debug().Msg(fmt.Sprintf("Admin server configured on %s", addr))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+		// pprof profiles and traces stream for up to 30s by default
+		WriteTimeout: 60 * time.Second,
+	}
+}
+
+func SetAdminToken(token string) {
+	adminToken.Store(&token)
+}
+
+// isAdminToken is always false when no token is configured, an admin server
+// without token is only trusted on its own listener.
+func isAdminToken(provided string) bool {
+	token := adminToken.Load()
+	if token == nil || *token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(*token)) == 1
+}
+
+func bearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+func adminAuth(middleware Middleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := adminToken.Load(); token == nil || *token == "" {
+			c.Next()
+			return
+		}
+
+		if !isAdminToken(bearerToken(c)) {
+			middleware.AbortWithError(c, exception.NewWithCode(exception.CodeHTTPUnauthorized, "invalid_admin_token"))
+			return
+		}
+
+		// the error responses of authenticated admins keep their debug output
+		c.Set(preference.CONTEXT_KEY_ADMIN, true)
+		c.Next()
+	}
+}
@@ -1,15 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	exception "learngolang/src/errors"
+	"learngolang/src/preference"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -22,26 +25,35 @@ type Auth interface {
 	GenerateToken(c *gin.Context, data any) (*TokenDetails, error)
 	ValidateToken(c *gin.Context) (*AccessDetails, error)
 	ValidateRefreshToken(c *gin.Context, token string) (*AccessDetails, error)
+	ClientIdentity(c *gin.Context) (*ClientIdentity, error)
+	ReloadKeys(opt AuthOptions) error
 }
 
 var onceAuth = &sync.Once{}
 
 type AuthOptions struct {
-	PrivateKey          string        `yaml:"private_key"`
-	PublicKey           string        `yaml:"public_key"`
+	PrivateKey string `yaml:"private_key"`
+	PublicKey  string `yaml:"public_key"`
+	// PrivateKeyPEM is used instead of the PrivateKey file when set, e.g. from a secret provider
+	PrivateKeyPEM       string        `yaml:"private_key_pem" secret:"true"`
 	ExpiredToken        time.Duration `yaml:"expired_token"`
 	ExpiredRefreshToken time.Duration `yaml:"expired_refresh_token"`
 }
 
 type auth struct {
-	log                 zerolog.Logger
-	redis               *redis.Client
-	privateKey          []byte
-	publicKey           []byte
+	log   zerolog.Logger
+	redis *redis.Client
+	// keys are swapped when the signing key is rotated
+	keys                atomic.Pointer[authKeys]
 	expiredToken        time.Duration
 	expiredRefreshToken time.Duration
 }
 
+type authKeys struct {
+	privateKey []byte
+	publicKey  []byte
+}
+
 type TokenDetails struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
@@ -62,35 +74,64 @@ func InitAuth(log zerolog.Logger, opt AuthOptions, redis *redis.Client) Auth {
 	var a *auth
 
 	onceAuth.Do(func() {
-		privateKey, err := os.ReadFile(opt.PrivateKey)
-		if err != nil {
-			log.Panic().Err(err).Send()
-		}
-
-		publicKey, err := os.ReadFile(opt.PublicKey)
+		keys, err := loadAuthKeys(opt)
 		if err != nil {
 			log.Panic().Err(err).Send()
 		}
 
 		a = &auth{
-			log:                 log,
+			log:                 WithLogComponent(log, LogComponentAuth),
 			redis:               redis,
-			privateKey:          privateKey,
-			publicKey:           publicKey,
 			expiredToken:        opt.ExpiredToken,
 			expiredRefreshToken: opt.ExpiredRefreshToken,
 		}
+		a.keys.Store(keys)
 	})
 
 	return a
 }
 
+func loadAuthKeys(opt AuthOptions) (*authKeys, error) {
+	privateKey := []byte(opt.PrivateKeyPEM)
+	if opt.PrivateKeyPEM == "" {
+		data, err := os.ReadFile(opt.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		privateKey = data
+	}
+
+	publicKey, err := os.ReadFile(opt.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authKeys{privateKey: privateKey, publicKey: publicKey}, nil
+}
+
+// ReloadKeys reads the keys again, the tokens signed with the previous key
+// fail the validation once the public key is rotated too.
+func (a *auth) ReloadKeys(opt AuthOptions) error {
+	keys, err := loadAuthKeys(opt)
+	if err != nil {
+		return err
+	}
+
+	previous := a.keys.Swap(keys)
+	if !bytes.Equal(previous.privateKey, keys.privateKey) || !bytes.Equal(previous.publicKey, keys.publicKey) {
+		a.log.Info().Msg("Auth keys rotated")
+	}
+
+	return nil
+}
+
 func (a *auth) GenerateToken(c *gin.Context, data any) (*TokenDetails, error) {
 	ctx := c.Request.Context()
 
 	td := &TokenDetails{}
 
-	key, err := jwt.ParseRSAPrivateKeyFromPEM(a.privateKey)
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(a.keys.Load().privateKey)
 	if err != nil {
 		return nil, exception.WrapWithCode(err, exception.CodeHTTPInternalServerError, "Failed to parse key")
 	}
@@ -153,7 +194,15 @@ func (a *auth) saveToRedis(ctx context.Context, publicID string, td *TokenDetail
 }
 
 func (a *auth) ValidateToken(c *gin.Context) (*AccessDetails, error) {
-	return a.checkingToken(c)
+	details, err := a.checkingToken(c)
+	if err != nil {
+		ComponentLogger(c.Request.Context(), LogComponentAuth).Debug().Err(err).Msg("token_rejected")
+		return nil, err
+	}
+
+	c.Set(preference.CONTEXT_KEY_USER, details.UserID)
+
+	return details, nil
 }
 
 func (a *auth) checkingToken(c *gin.Context) (*AccessDetails, error) {
@@ -170,8 +219,15 @@ func (a *auth) checkingToken(c *gin.Context) (*AccessDetails, error) {
 		return nil, exception.WrapWithCode(err, exception.CodeHTTPUnauthorized, "Invalid token")
 	}
 
-	userID := claims["user_id"].(string)
-	username := claims["name"].(string)
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Failed claims userID")
+	}
+
+	username, ok := claims["name"].(string)
+	if !ok {
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Failed claims username")
+	}
 
 	var accessUUID, redisIDUser string
 
@@ -197,7 +253,7 @@ func (a *auth) checkingToken(c *gin.Context) (*AccessDetails, error) {
 }
 
 func (a *auth) extractToken(c *gin.Context) string {
-	bearToken := c.Request.Header["Authorization"][0]
+	bearToken := c.GetHeader("Authorization")
 	if len(bearToken) == 0 {
 		return ""
 	}
@@ -211,7 +267,7 @@ func (a *auth) extractToken(c *gin.Context) string {
 }
 
 func (a *auth) verifyToken(tokenStr string) (*jwt.Token, error) {
-	key, err := jwt.ParseRSAPublicKeyFromPEM(a.publicKey)
+	key, err := jwt.ParseRSAPublicKeyFromPEM(a.keys.Load().publicKey)
 	if err != nil {
 		return nil, exception.WrapWithCode(err, exception.CodeHTTPInternalServerError, "Failed to parse key")
 	}
@@ -243,14 +299,21 @@ func (a *auth) ValidateRefreshToken(c *gin.Context, tokenStr string) (*AccessDet
 		return nil, exception.WrapWithCode(err, exception.CodeHTTPUnauthorized, "Invalid token")
 	}
 
-	userID := claims["user_id"].(string)
-	username := claims["name"].(string)
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Failed claims userID")
+	}
+
+	username, ok := claims["name"].(string)
+	if !ok {
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Failed claims username")
+	}
 
 	var accessUUID, refreshUUID, redisIDUser string
 
 	refreshUUID, ok = claims["refresh_uuid"].(string)
 	if !ok {
-		return nil, err
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Failed claims refreshUUID")
 	}
 
 	redisIDUser, err = a.redis.Get(ctx, refreshUUID).Result()
@@ -269,3 +332,13 @@ func (a *auth) ValidateRefreshToken(c *gin.Context, tokenStr string) (*AccessDet
 		Username:    username,
 	}, nil
 }
+
+// ClientIdentity returns the verified client certificate of a mutual TLS request.
+func (a *auth) ClientIdentity(c *gin.Context) (*ClientIdentity, error) {
+	identity := ClientIdentityFromTLS(c.Request.TLS)
+	if identity == nil {
+		return nil, exception.NewWithCode(exception.CodeHTTPUnauthorized, "Client certificate required")
+	}
+
+	return identity, nil
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"learngolang/src/preference"
@@ -18,7 +23,8 @@ type DatabaseOptions struct {
 	Host            string        `yaml:"host"`
 	Port            int           `yaml:"port"`
 	User            string        `yaml:"user"`
-	Password        string        `yaml:"password"`
+	Password        string        `yaml:"password" secret:"true"`
+	PasswordFile    string        `yaml:"password_file"`
 	DBName          string        `yaml:"dbname"`
 	SSLMode         bool          `yaml:"sslmode"`
 	MaxOpenConns    int           `yaml:"max_open_conns"`
@@ -27,6 +33,35 @@ type DatabaseOptions struct {
 	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 }
 
+// dbConnectors keeps the connector of every pool, the credentials of the pool
+// are rotated through it
+var dbConnectors sync.Map
+
+// rotatingConnector opens every new connection with the current dsn, so a
+// rotated password applies without rebuilding the pool the repositories hold.
+type rotatingConnector struct {
+	driver driver.Driver
+	dsn    atomic.Pointer[string]
+}
+
+func (c *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn := *c.dsn.Load()
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		return connector.Connect(ctx)
+	}
+
+	return c.driver.Open(dsn)
+}
+
+func (c *rotatingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
 func InitDB(log zerolog.Logger, opt DatabaseOptions) *sqlx.DB {
 	if !opt.Enabled {
 		return nil
@@ -37,7 +72,7 @@ func InitDB(log zerolog.Logger, opt DatabaseOptions) *sqlx.DB {
 		log.Panic().Err(err).Msg(fmt.Sprintf("%s status: FAILED", strings.ToUpper(opt.Driver)))
 	}
 
-	db, err := sqlx.Connect(driver, host)
+	db, err := connectDB(driver, host)
 	if err != nil {
 		log.Panic().Err(err).Msg(fmt.Sprintf("%s status: FAILED", strings.ToUpper(opt.Driver)))
 	}
@@ -52,6 +87,51 @@ func InitDB(log zerolog.Logger, opt DatabaseOptions) *sqlx.DB {
 	return db
 }
 
+func connectDB(driverName string, dsn string) (*sqlx.DB, error) {
+	// the registered driver is only reachable through a handle, opening one connects nothing
+	probe, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	connector := &rotatingConnector{driver: probe.Driver()}
+	connector.dsn.Store(&dsn)
+	_ = probe.Close()
+
+	db := sqlx.NewDb(sql.OpenDB(connector), driverName)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	dbConnectors.Store(db, connector)
+
+	return db, nil
+}
+
+// RotateDBCredentials opens the next connections of db with the credentials
+// of opt and closes the idle ones, the connections in use keep their session
+// until conn_max_lifetime.
+func RotateDBCredentials(log zerolog.Logger, db *sqlx.DB, opt DatabaseOptions) {
+	value, ok := dbConnectors.Load(db)
+	if db == nil || !ok {
+		return
+	}
+
+	_, dsn, err := getURI(opt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to rotate database credentials")
+		return
+	}
+
+	value.(*rotatingConnector).dsn.Store(&dsn)
+
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(opt.MaxIdleConns)
+
+	log.Info().Str("driver", opt.Driver).Msg("Database credentials rotated")
+}
+
 func getURI(opt DatabaseOptions) (string, string, error) {
 	switch opt.Driver {
 	case preference.POSTGRES:
@@ -7,14 +7,28 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func InitHttpGin(log zerolog.Logger, middleware Middleware) *gin.Engine {
+func InitHttpGin(log zerolog.Logger, opt ServerOptions, middleware Middleware, metrics *Metrics, health *Health) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
+	if err := router.SetTrustedProxies(opt.TrustedProxies); err != nil {
+		log.Panic().Err(err).Msg("Invalid trusted proxies")
+	}
+
 	router.Use(middleware.Handler())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS())
+	router.Use(middleware.SecurityHeaders())
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, ginSwagger.DefaultModelsExpandDepth(-1)))
 
+	router.GET("/livez", health.Livez)
+	router.GET("/readyz", health.Readyz)
+
+	if metrics != nil {
+		router.GET(metrics.Path(), gin.WrapH(metrics.Handler()))
+	}
+
 	return router
 }
@@ -2,8 +2,8 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -13,85 +13,202 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const defaultShutdownTimeout = 5 * time.Second
+
 var (
 	onceGrace = &sync.Once{}
-	wg        sync.WaitGroup
+
+	// fatalCh receives errors of components that died after a successful start
+	fatalCh = make(chan error, 1)
 )
 
 type App interface {
-	Serve()
+	Register(hooks ...Hook)
+	Serve() error
+}
+
+// Hook is a component managed by the app lifecycle. Hooks are started in
+// registration order and stopped in reverse order, so a component must be
+// registered after everything it depends on.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
 }
 
 type app struct {
-	log        zerolog.Logger
-	httpServer *http.Server
+	log             zerolog.Logger
+	health          *Health
+	shutdownTimeout time.Duration
+	upgradeTimeout  time.Duration
+	mu              sync.Mutex
+	hooks           []Hook
 }
 
-func InitGrace(log zerolog.Logger, httpServer *http.Server) App {
+func InitGrace(log zerolog.Logger, opt ServerOptions, health *Health) App {
 	var gs *app
 
 	onceGrace.Do(func() {
+		shutdownTimeout := opt.ShutdownTimeout
+		if shutdownTimeout <= 0 {
+			shutdownTimeout = defaultShutdownTimeout
+		}
+
 		gs = &app{
-			log:        log,
-			httpServer: httpServer,
+			log:             log,
+			health:          health,
+			shutdownTimeout: shutdownTimeout,
+			upgradeTimeout:  opt.UpgradeTimeout,
+			hooks:           make([]Hook, 0),
 		}
 	})
 
 	return gs
 }
 
-func (g *app) Serve() {
-	ctx, cancel := context.WithCancel(context.Background())
+func (g *app) Register(hooks ...Hook) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
+	g.hooks = append(g.hooks, hooks...)
+}
+
+func (g *app) Serve() error {
 	// Listen for termination signals
-	signalCh := make(chan os.Signal, 1)
+	signalCh := make(chan os.Signal, 2)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signalCh)
 
-	wg.Add(1)
-	go startHTTPServer(ctx, &wg, g.log, g.httpServer)
+	// SIGUSR2 hands the listeners over to a new process before draining this one
+	upgradeCh := make(chan os.Signal, 1)
+	signal.Notify(upgradeCh, syscall.SIGUSR2)
+	defer signal.Stop(upgradeCh)
 
-	// Wait for termination signal
-	<-signalCh
+	g.mu.Lock()
+	hooks := g.hooks
+	g.mu.Unlock()
 
-	// Start the graceful shutdown process
-	g.log.Debug().Msg("Gracefully shutting down HTTP server...")
+	started, err := g.start(hooks)
+	if err != nil {
+		g.log.Error().Err(err).Msg("Startup failed, stopping started components...")
+		g.stop(started)
 
-	// Cancel the context to signal the HTTP server to stop
-	cancel()
+		return err
+	}
 
-	// Wait for the HTTP server to finish
-	wg.Wait()
+	if err := checkInheritedUsed(); err != nil {
+		g.log.Error().Err(err).Msg("Startup failed, stopping started components...")
+		g.stop(started)
 
-	g.log.Debug().Msg("Shutdown complete.")
-}
+		return err
+	}
+
+	// The parent of an upgrade drains once every listener is served here
+	notifyUpgradeReady(g.log)
 
-func startHTTPServer(ctx context.Context, wg *sync.WaitGroup, log zerolog.Logger, httpServer *http.Server) {
-	defer wg.Done()
+	// Wait for termination signal, a component failure or a successful upgrade
+	err = g.wait(signalCh, upgradeCh)
 
-	// Start the HTTP server in a separate goroutine
+	done := make(chan struct{})
 	go func() {
-		log.Debug().Msg("Starting HTTP server...")
-		log.Debug().Msg(fmt.Sprintf("HTTP server start on %s", httpServer.Addr))
+		defer close(done)
+		g.shutdown(started)
+	}()
+
+	// A second signal skips the graceful shutdown
+	select {
+	case <-done:
+	case sig := <-signalCh:
+		g.log.Warn().Str("signal", sig.String()).Msg("Second signal received, forcing exit")
+		os.Exit(1)
+	}
+
+	g.log.Info().Msg("Shutdown complete.")
 
-		err := httpServer.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Error().AnErr("HTTP server error", err)
+	return err
+}
+
+func (g *app) wait(signalCh <-chan os.Signal, upgradeCh <-chan os.Signal) error {
+	for {
+		select {
+		case sig := <-signalCh:
+			g.log.Info().Str("signal", sig.String()).Msg("Shutdown signal received")
+			return nil
+		case err := <-fatalCh:
+			g.log.Error().Err(err).Msg("Component failed, shutting down")
+			return err
+		case <-upgradeCh:
+			// a failed upgrade keeps this process serving
+			if err := upgrade(g.log, g.upgradeTimeout); err != nil {
+				g.log.Error().Err(err).Msg("Upgrade aborted, keep serving")
+				continue
+			}
+
+			return nil
 		}
-	}()
+	}
+}
 
-	// Wait for the context to be canceled
-	<-ctx.Done()
-	log.Debug().Msg("HTTP server started...")
+func (g *app) start(hooks []Hook) ([]Hook, error) {
+	started := make([]Hook, 0, len(hooks))
 
-	// Shutdown the server gracefully
-	log.Debug().Msg("Shutting down HTTP server gracefully...")
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelShutdown()
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			g.log.Debug().Str("component", hook.Name).Msg("Starting component...")
 
-	err := httpServer.Shutdown(shutdownCtx)
-	if err != nil {
-		log.Debug().AnErr("HTTP server shutdown error", err)
+			if err := hook.OnStart(context.Background()); err != nil {
+				return started, fmt.Errorf("start %s: %w", hook.Name, err)
+			}
+		}
+
+		started = append(started, hook)
 	}
 
-	log.Debug().Msg("HTTP server stopped.")
+	return started, nil
+}
+
+func (g *app) shutdown(started []Hook) {
+	// Readiness fails before any listener closes
+	g.health.MarkShuttingDown()
+	if delay := g.health.ShutdownDelay(); delay > 0 {
+		g.log.Debug().Msg(fmt.Sprintf("Readiness failing, waiting %s before shutting down...", delay))
+		time.Sleep(delay)
+	}
+
+	g.log.Debug().Msg(fmt.Sprintf("Gracefully shutting down, deadline %s...", g.shutdownTimeout))
+	g.stop(started)
+}
+
+// stop runs the stop hooks in reverse order, all of them share the configured deadline.
+func (g *app) stop(started []Hook) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.shutdownTimeout)
+	defer cancel()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		hook := started[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		start := time.Now()
+		err := hook.OnStop(ctx)
+
+		event := g.log.Debug()
+		if err != nil {
+			event = g.log.Error().Err(err)
+		}
+
+		event.Str("component", hook.Name).Str("took", time.Since(start).String()).Msg("Component stopped")
+	}
+}
+
+func reportFatal(err error) {
+	select {
+	case fatalCh <- err:
+	default:
+	}
+}
+
+func isContextDone(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
 }
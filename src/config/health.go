@@ -0,0 +1,199 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"learngolang/src/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	HealthStatusOK   string = "ok"
+	HealthStatusFail string = "fail"
+
+	defaultHealthTimeout = 2 * time.Second
+)
+
+var onceHealth = &sync.Once{}
+
+type HealthOptions struct {
+	Timeout time.Duration `yaml:"timeout"`
+	// ShutdownDelay keeps serving while readiness is failing, so load balancers stop routing first
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+}
+
+type HealthCheckFunc func(ctx context.Context) error
+
+type healthCheck struct {
+	name  string
+	check HealthCheckFunc
+}
+
+type Health struct {
+	log          zerolog.Logger
+	opt          HealthOptions
+	mu           sync.RWMutex
+	checks       []healthCheck
+	shuttingDown atomic.Bool
+}
+
+func InitHealth(log zerolog.Logger, opt HealthOptions) *Health {
+	var h *Health
+
+	onceHealth.Do(func() {
+		if opt.Timeout <= 0 {
+			opt.Timeout = defaultHealthTimeout
+		}
+
+		h = &Health{
+			log:    log,
+			opt:    opt,
+			checks: make([]healthCheck, 0),
+		}
+	})
+
+	return h
+}
+
+func (h *Health) AddCheck(name string, check HealthCheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, healthCheck{name: name, check: check})
+}
+
+func (h *Health) AddSQL(name string, db *sqlx.DB) {
+	if db == nil {
+		return
+	}
+
+	h.AddCheck(name, db.PingContext)
+}
+
+func (h *Health) AddRedis(name string, client *redis.Client) {
+	if client == nil {
+		return
+	}
+
+	h.AddCheck(name, func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// MarkShuttingDown makes readiness fail from now on, it is called as soon as
+// graceful shutdown starts and before any listener is closed.
+func (h *Health) MarkShuttingDown() {
+	if h == nil {
+		return
+	}
+
+	h.shuttingDown.Store(true)
+}
+
+func (h *Health) ShutdownDelay() time.Duration {
+	if h == nil {
+		return 0
+	}
+
+	return h.opt.ShutdownDelay
+}
+
+func (h *Health) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.HealthResp{
+		Status:    HealthStatusOK,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readyz is public, the errors of the dependencies reveal hosts and credentials
+// failures so they are left to the log and to ReadyzDetail.
+func (h *Health) Readyz(c *gin.Context) {
+	h.readyz(c, false)
+}
+
+// ReadyzDetail is Readyz with the errors of the dependencies, for the admin server.
+func (h *Health) ReadyzDetail(c *gin.Context) {
+	h.readyz(c, true)
+}
+
+func (h *Health) readyz(c *gin.Context, detailed bool) {
+	resp := dto.HealthResp{
+		Status:    HealthStatusOK,
+		Checks:    h.runChecks(c.Request.Context(), detailed),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	for _, check := range resp.Checks {
+		if check.Status != HealthStatusOK {
+			resp.Status = HealthStatusFail
+		}
+	}
+
+	if h.shuttingDown.Load() {
+		resp.Status = HealthStatusFail
+		resp.Checks["shutdown"] = dto.HealthCheck{Status: HealthStatusFail, Error: "graceful shutdown in progress"}
+	}
+
+	statusCode := http.StatusOK
+	if resp.Status != HealthStatusOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, resp)
+}
+
+func (h *Health) runChecks(ctx context.Context, detailed bool) map[string]dto.HealthCheck {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]dto.HealthCheck, len(checks))
+	)
+
+	for _, hc := range checks {
+		wg.Add(1)
+
+		go func(hc healthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.opt.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := hc.check(checkCtx)
+
+			result := dto.HealthCheck{
+				Status:  HealthStatusOK,
+				Latency: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			if err != nil {
+				result.Status = HealthStatusFail
+				if detailed {
+					result.Error = err.Error()
+				}
+
+				h.log.Warn().Err(err).Str("dependency", hc.name).Msg("health_check_failed")
+			}
+
+			mu.Lock()
+			results[hc.name] = result
+			mu.Unlock()
+		}(hc)
+	}
+
+	wg.Wait()
+
+	return results
+}
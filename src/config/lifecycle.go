@@ -0,0 +1,249 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const maxDroppedRequestsLogged = 20
+
+// HTTPServerHook binds the listener on start, so a busy port fails the startup,
+// and drains the server on stop. Requests still running at the deadline are logged.
+// An inherited socket registered under the same name is used instead of binding.
+func HTTPServerHook(log zerolog.Logger, name string, httpServer *http.Server) Hook {
+	tracker := &inFlightTracker{}
+	httpServer.Handler = tracker.wrap(httpServer.Handler)
+
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			ln, err := Listen(name, httpServer.Addr)
+			if err != nil {
+				return err
+			}
+
+			log.Info().Str("server", name).Bool("tls", httpServer.TLSConfig != nil).Msg(fmt.Sprintf("HTTP server start on %s", ln.Addr()))
+
+			go func() {
+				serve := httpServer.Serve
+				if httpServer.TLSConfig != nil {
+					// certificates come from the TLS config, so no files are passed here
+					serve = func(ln net.Listener) error {
+						return httpServer.ServeTLS(ln, "", "")
+					}
+				}
+
+				if err := serve(ln); err != nil && err != http.ErrServerClosed {
+					log.Error().Err(err).Str("server", name).Msg("HTTP server error")
+					reportFatal(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := httpServer.Shutdown(ctx)
+			if err != nil && isContextDone(err) {
+				dropped := tracker.snapshot()
+				log.Warn().
+					Str("server", name).
+					Int("dropped", len(dropped)).
+					Strs("requests", dropped).
+					Msg("Shutdown deadline exceeded, dropping in-flight requests")
+
+				return httpServer.Close()
+			}
+
+			return err
+		},
+	}
+}
+
+func HTTP3ServerHook(log zerolog.Logger, name string, h3Server *http3.Server) Hook {
+	// the QUIC requests are tracked like the TCP ones, the handler was copied
+	// from the HTTP server before its hook wrapped it
+	tracker := &inFlightTracker{}
+	h3Server.Handler = tracker.wrap(h3Server.Handler)
+
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			conn, err := ListenPacket(name, h3Server.Addr)
+			if err != nil {
+				return err
+			}
+
+			log.Info().Str("server", name).Msg(fmt.Sprintf("HTTP/3 server start on udp %s", conn.LocalAddr()))
+
+			go func() {
+				if err := h3Server.Serve(conn); err != nil && err != http.ErrServerClosed && err != quic.ErrServerClosed {
+					log.Error().Err(err).Str("server", name).Msg("HTTP/3 server error")
+					reportFatal(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := h3Server.Shutdown(ctx)
+			if err != nil && isContextDone(err) {
+				dropped := tracker.snapshot()
+				log.Warn().
+					Str("server", name).
+					Int("dropped", len(dropped)).
+					Strs("requests", dropped).
+					Msg("Shutdown deadline exceeded, closing HTTP/3 connections")
+
+				return h3Server.Close()
+			}
+
+			return err
+		},
+	}
+}
+
+// ReloadHook reloads the config on SIGHUP until the app stops.
+func ReloadHook(reloader *Reloader) Hook {
+	signalCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	return Hook{
+		Name: "reload",
+		OnStart: func(ctx context.Context) error {
+			signal.Notify(signalCh, syscall.SIGHUP)
+
+			go func() {
+				for {
+					select {
+					case <-signalCh:
+						_, _ = reloader.Reload(context.Background())
+					case <-done:
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			signal.Stop(signalCh)
+			close(done)
+
+			return nil
+		},
+	}
+}
+
+func SchedulerHook(scheduler *Scheduler) Hook {
+	return Hook{
+		Name: "scheduler",
+		OnStart: func(ctx context.Context) error {
+			if scheduler != nil {
+				scheduler.Start()
+			}
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if scheduler == nil {
+				return nil
+			}
+
+			return scheduler.Stop(ctx)
+		},
+	}
+}
+
+func TracingHook(tracing *Tracing) Hook {
+	return Hook{
+		Name:   "tracing",
+		OnStop: tracing.Shutdown,
+	}
+}
+
+func RedisHook(name string, client *redis.Client) Hook {
+	return Hook{
+		Name: name,
+		OnStop: func(ctx context.Context) error {
+			if client == nil {
+				return nil
+			}
+
+			return client.Close()
+		},
+	}
+}
+
+func SQLHook(name string, db *sqlx.DB) Hook {
+	return Hook{
+		Name: name,
+		OnStop: func(ctx context.Context) error {
+			if db == nil {
+				return nil
+			}
+
+			return db.Close()
+		},
+	}
+}
+
+type inFlightTracker struct {
+	seq      atomic.Uint64
+	requests sync.Map
+}
+
+type inFlightRequest struct {
+	method string
+	path   string
+	start  time.Time
+}
+
+func (t *inFlightTracker) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := t.seq.Add(1)
+		t.requests.Store(id, inFlightRequest{method: r.Method, path: r.URL.Path, start: time.Now()})
+		defer t.requests.Delete(id)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// snapshot returns the oldest in-flight requests formatted for the shutdown log.
+func (t *inFlightTracker) snapshot() []string {
+	requests := make([]inFlightRequest, 0)
+	t.requests.Range(func(_, value any) bool {
+		requests = append(requests, value.(inFlightRequest))
+		return true
+	})
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].start.Before(requests[j].start)
+	})
+
+	result := make([]string, 0, min(len(requests), maxDroppedRequestsLogged))
+	for i, req := range requests {
+		if i == maxDroppedRequestsLogged {
+			result = append(result, fmt.Sprintf("... and %d more", len(requests)-i))
+			break
+		}
+
+		result = append(result, fmt.Sprintf("%s %s (%s)", req.method, req.path, time.Since(req.start).Truncate(time.Millisecond)))
+	}
+
+	return result
+}
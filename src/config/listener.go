@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// listenFdsStart is the first inherited file descriptor, see sd_listen_fds(3)
+	listenFdsStart = 3
+
+	envListenFds     string = "LISTEN_FDS"
+	envListenFdNames string = "LISTEN_FDNAMES"
+	envListenPid     string = "LISTEN_PID"
+
+	upgradeReadyFdName string = "upgrade_ready"
+
+	// defaultListenerName is the main HTTP server, it takes a single inherited
+	// socket whatever its name
+	defaultListenerName string = "http"
+
+	defaultUpgradeTimeout = 30 * time.Second
+)
+
+// listeners keeps the sockets inherited on start and the ones in use, so they
+// can be handed over to a new process on upgrade.
+var listeners = &listenerRegistry{
+	inherited: make(map[string]*os.File),
+	active:    make(map[string]*os.File),
+}
+
+type listenerRegistry struct {
+	mu        sync.Mutex
+	once      sync.Once
+	inherited map[string]*os.File
+	active    map[string]*os.File
+	order     []string
+}
+
+// IsInheritedStart reports whether the process was started with inherited
+// sockets, either by systemd socket activation or by an upgrade.
+func IsInheritedStart() bool {
+	return os.Getenv(envListenFds) != ""
+}
+
+// loadInherited reads the sockets passed with the systemd socket activation
+// protocol. LISTEN_PID is optional because a re-exec'ed child cannot know its
+// own pid in advance.
+func (r *listenerRegistry) loadInherited() {
+	r.once.Do(func() {
+		count, err := strconv.Atoi(os.Getenv(envListenFds))
+		if err != nil || count < 1 {
+			return
+		}
+
+		if pid := os.Getenv(envListenPid); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+			return
+		}
+
+		names := strings.Split(os.Getenv(envListenFdNames), ":")
+		for i := 0; i < count; i++ {
+			fd := listenFdsStart + i
+			syscall.CloseOnExec(fd)
+
+			name := strconv.Itoa(fd)
+			if i < len(names) && names[i] != "" {
+				name = names[i]
+			}
+
+			r.inherited[name] = os.NewFile(uintptr(fd), name)
+		}
+
+		// the sockets must not leak into processes spawned later on
+		for _, key := range []string{envListenFds, envListenFdNames, envListenPid} {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+func (r *listenerRegistry) take(name string) (*os.File, bool) {
+	r.loadInherited()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, ok := r.inherited[name]
+	if !ok && name == defaultListenerName {
+		// systemd names a socket after its unit, or after its fd without
+		// LISTEN_FDNAMES, unless FileDescriptorName is set
+		if unclaimed := r.unclaimedLocked(); len(unclaimed) == 1 {
+			name = unclaimed[0]
+			file, ok = r.inherited[name]
+		}
+	}
+
+	if ok {
+		delete(r.inherited, name)
+	}
+
+	return file, ok
+}
+
+// unclaimedLocked returns the names of the inherited sockets no server took yet.
+func (r *listenerRegistry) unclaimedLocked() []string {
+	names := make([]string, 0, len(r.inherited))
+	for name := range r.inherited {
+		if name != upgradeReadyFdName {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// checkInheritedUsed fails when a server did not take its inherited socket,
+// it would otherwise bind a port systemd or the parent process still holds.
+func checkInheritedUsed() error {
+	listeners.loadInherited()
+
+	listeners.mu.Lock()
+	defer listeners.mu.Unlock()
+
+	if unclaimed := listeners.unclaimedLocked(); len(unclaimed) > 0 {
+		return fmt.Errorf("inherited sockets %s are not used, set FileDescriptorName= of the socket unit to the server name (http, http3 or admin)", strings.Join(unclaimed, ", "))
+	}
+
+	return nil
+}
+
+func (r *listenerRegistry) track(name string, file *os.File) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.active[name]; !ok {
+		r.order = append(r.order, name)
+	}
+
+	r.active[name] = file
+}
+
+// Listen returns the inherited TCP listener registered under name, or binds
+// a new one on addr.
+func Listen(name string, addr string) (net.Listener, error) {
+	if file, ok := listeners.take(name); ok {
+		ln, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited listener %s: %w", name, err)
+		}
+
+		return ln, listeners.trackListener(name, ln)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return ln, listeners.trackListener(name, ln)
+}
+
+// ListenPacket is the UDP counterpart of Listen.
+func ListenPacket(name string, addr string) (net.PacketConn, error) {
+	if file, ok := listeners.take(name); ok {
+		conn, err := net.FilePacketConn(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited packet conn %s: %w", name, err)
+		}
+
+		return conn, listeners.trackPacketConn(name, conn)
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, listeners.trackPacketConn(name, conn)
+}
+
+func (r *listenerRegistry) trackListener(name string, ln net.Listener) error {
+	tcpLn, ok := ln.(*net.TCPListener)
+	if !ok {
+		return nil
+	}
+
+	file, err := tcpLn.File()
+	if err != nil {
+		return err
+	}
+
+	r.track(name, file)
+
+	return nil
+}
+
+func (r *listenerRegistry) trackPacketConn(name string, conn net.PacketConn) error {
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		return nil
+	}
+
+	file, err := udpConn.File()
+	if err != nil {
+		return err
+	}
+
+	r.track(name, file)
+
+	return nil
+}
+
+// notifyUpgradeReady tells the parent process that this process is serving,
+// so the parent can start draining.
+func notifyUpgradeReady(log zerolog.Logger) {
+	file, ok := listeners.take(upgradeReadyFdName)
+	if !ok {
+		return
+	}
+
+	defer file.Close()
+
+	if _, err := file.Write([]byte{1}); err != nil {
+		log.Warn().Err(err).Msg("Failed to notify parent process")
+	}
+}
+
+// upgrade re-executes the binary with every active socket and waits until the
+// child is serving. The caller drains this process afterwards.
+func upgrade(log zerolog.Logger, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultUpgradeTimeout
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer readyReader.Close()
+
+	listeners.mu.Lock()
+	names := make([]string, 0, len(listeners.order)+1)
+	files := make([]*os.File, 0, len(listeners.order)+1)
+	for _, name := range listeners.order {
+		names = append(names, name)
+		files = append(files, listeners.active[name])
+	}
+	listeners.mu.Unlock()
+
+	names = append(names, upgradeReadyFdName)
+	files = append(files, readyWriter)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", envListenFds, len(files)),
+		fmt.Sprintf("%s=%s", envListenFdNames, strings.Join(names, ":")),
+	)
+
+	if err := cmd.Start(); err != nil {
+		_ = readyWriter.Close()
+		return err
+	}
+
+	// only the child holds the write end now, so EOF means it died before being ready
+	_ = readyWriter.Close()
+
+	log.Info().Int("child_pid", cmd.Process.Pid).Strs("listeners", names[:len(names)-1]).Msg("Upgrade started, waiting for the new process")
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyReader.Read(buf)
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process not ready after %s", timeout)
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return fmt.Errorf("upgrade failed: %w", err)
+	}
+
+	// the child is re-parented to init once this process exits
+	go func() { _ = cmd.Wait() }()
+
+	log.Info().Int("child_pid", cmd.Process.Pid).Msg("New process is serving, draining this one")
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestListenerRegistryTake(t *testing.T) {
+	tests := []struct {
+		name      string
+		inherited []string
+		take      string
+		want      string
+	}{
+		{name: "named", inherited: []string{"http", "admin"}, take: "http", want: "http"},
+		{name: "single unnamed", inherited: []string{"3"}, take: "http", want: "3"},
+		{name: "single socket unit", inherited: []string{"app.socket"}, take: "http", want: "app.socket"},
+		{name: "single with the upgrade pipe", inherited: []string{"app.socket", upgradeReadyFdName}, take: "http", want: "app.socket"},
+		{name: "several unnamed", inherited: []string{"3", "4"}, take: "http", want: ""},
+		{name: "only http falls back", inherited: []string{"3"}, take: "admin", want: ""},
+		{name: "nothing inherited", inherited: nil, take: "http", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &listenerRegistry{inherited: make(map[string]*os.File), active: make(map[string]*os.File)}
+			r.once.Do(func() {})
+
+			// not an open fd, closing it is harmless
+			for _, name := range tt.inherited {
+				r.inherited[name] = os.NewFile(1<<20, name)
+			}
+
+			file, ok := r.take(tt.take)
+			got := ""
+			if ok {
+				got = file.Name()
+			}
+
+			if got != tt.want {
+				t.Errorf("take(%q) = %q, want %q", tt.take, got, tt.want)
+			}
+
+			if _, left := r.inherited[got]; ok && left {
+				t.Errorf("take(%q) left %q registered", tt.take, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"learngolang/src/preference"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	LogComponentRepository string = "repository"
+	LogComponentAuth       string = "auth"
+	LogComponentScheduler  string = "scheduler"
+	LogComponentMiddleware string = "middleware"
+
+	// logComponentBase is the level of every logger without a component
+	logComponentBase string = ""
+)
+
+var LogComponents = []string{LogComponentRepository, LogComponentAuth, LogComponentScheduler, LogComponentMiddleware}
+
+// logLevelSettings is swapped as a whole, the level of every event is checked
+// against it so it is read without a lock.
+type logLevelSettings struct {
+	base       zerolog.Level
+	components map[string]zerolog.Level
+}
+
+var (
+	logLevels atomic.Pointer[logLevelSettings]
+	// logLevelMu serializes the writers of logLevels and of the global level
+	logLevelMu    sync.Mutex
+	debugRequests atomic.Int64
+	// logWriter is the writer of the root logger before any level gate
+	logWriter zerolog.LevelWriter
+)
+
+func init() {
+	logLevels.Store(&logLevelSettings{base: zerolog.DebugLevel, components: map[string]zerolog.Level{}})
+}
+
+// componentLevelWriter drops the events below the current level of its component.
+type componentLevelWriter struct {
+	writer    zerolog.LevelWriter
+	component string
+}
+
+func (w *componentLevelWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+func (w *componentLevelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < componentLevel(w.component) {
+		return len(p), nil
+	}
+
+	return w.writer.WriteLevel(level, p)
+}
+
+func componentLevel(component string) zerolog.Level {
+	settings := logLevels.Load()
+	if level, ok := settings.components[component]; ok {
+		return level
+	}
+
+	return settings.base
+}
+
+// LogLevel returns the base level and the component overrides.
+func LogLevel() (zerolog.Level, map[string]zerolog.Level) {
+	settings := logLevels.Load()
+
+	return settings.base, maps.Clone(settings.components)
+}
+
+// SetLogLevel changes the level of every logger without a component override.
+func SetLogLevel(level zerolog.Level) {
+	logLevelMu.Lock()
+	defer logLevelMu.Unlock()
+
+	settings := logLevels.Load()
+	logLevels.Store(&logLevelSettings{base: level, components: settings.components})
+	applyGlobalLevel()
+}
+
+// SetComponentLogLevel overrides the base level for a single component.
+func SetComponentLogLevel(component string, level zerolog.Level) error {
+	if !isLogComponent(component) {
+		return fmt.Errorf("unknown log component %q", component)
+	}
+
+	logLevelMu.Lock()
+	defer logLevelMu.Unlock()
+
+	settings := logLevels.Load()
+	components := maps.Clone(settings.components)
+	components[component] = level
+	logLevels.Store(&logLevelSettings{base: settings.base, components: components})
+	applyGlobalLevel()
+
+	return nil
+}
+
+// ResetComponentLogLevel removes the override, the component follows the base level again.
+func ResetComponentLogLevel(component string) error {
+	if !isLogComponent(component) {
+		return fmt.Errorf("unknown log component %q", component)
+	}
+
+	logLevelMu.Lock()
+	defer logLevelMu.Unlock()
+
+	settings := logLevels.Load()
+	components := maps.Clone(settings.components)
+	delete(components, component)
+	logLevels.Store(&logLevelSettings{base: settings.base, components: components})
+	applyGlobalLevel()
+
+	return nil
+}
+
+func isLogComponent(component string) bool {
+	return slices.Contains(LogComponents, component)
+}
+
+// applyGlobalLevel lowers the zerolog global level to the most verbose level
+// in use, zerolog drops anything below it before the level gates are reached.
+func applyGlobalLevel() {
+	settings := logLevels.Load()
+
+	level := settings.base
+	for _, l := range settings.components {
+		level = min(level, l)
+	}
+
+	if debugRequests.Load() > 0 {
+		level = min(level, zerolog.DebugLevel)
+	}
+
+	zerolog.SetGlobalLevel(level)
+}
+
+// beginDebugRequest keeps debug events enabled until the returned func is called.
+func beginDebugRequest() func() {
+	logLevelMu.Lock()
+	debugRequests.Add(1)
+	applyGlobalLevel()
+	logLevelMu.Unlock()
+
+	return func() {
+		logLevelMu.Lock()
+		debugRequests.Add(-1)
+		applyGlobalLevel()
+		logLevelMu.Unlock()
+	}
+}
+
+func isDebugLogRequest(ctx context.Context) bool {
+	debug, _ := ctx.Value(preference.CONTEXT_KEY_DEBUG_LOG).(bool)
+	return debug
+}
+
+// WithLogComponent returns the logger writing at the level of the component.
+func WithLogComponent(log zerolog.Logger, component string) zerolog.Logger {
+	if logWriter == nil {
+		return log
+	}
+
+	return log.Output(&componentLevelWriter{writer: logWriter, component: component})
+}
+
+// ComponentLogger is zerolog.Ctx at the level of the component, the logger of
+// a request with debug logging enabled is returned as is.
+func ComponentLogger(ctx context.Context, component string) *zerolog.Logger {
+	log := zerolog.Ctx(ctx)
+	if isDebugLogRequest(ctx) {
+		return log
+	}
+
+	componentLog := WithLogComponent(*log, component)
+
+	return &componentLog
+}
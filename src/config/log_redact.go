@@ -0,0 +1,333 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const (
+	LogRedactionAuto string = "auto"
+	LogRedactionOn   string = "on"
+	LogRedactionOff  string = "off"
+
+	redactedLogValue string = "[REDACTED]"
+)
+
+var (
+	defaultRedactedFields   = []string{"email", "password", "token", "authorization"}
+	defaultRedactedPatterns = []string{"email", "jwt", "card_number"}
+
+	// logRedactionPatterns are the built in patterns, any other entry of
+	// LogRedactionOptions.Patterns is compiled as a regular expression
+	logRedactionPatterns = map[string]redactionPattern{
+		"email": {re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		"jwt":   {re: regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)},
+		// the luhn check keeps ids and phone numbers readable
+		"card_number": {re: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`), valid: isLuhn},
+	}
+
+	// bodyRedactor masks the sampled bodies of the access log, they are
+	// redacted even when the events are not
+	bodyRedactor, _ = newRedactWriter(nil, LogRedactionOptions{})
+
+	// jsonStringRegex matches a json string, the trailing colon tells a key from a value
+	jsonStringRegex = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(:?)`)
+)
+
+type redactionPattern struct {
+	re *regexp.Regexp
+	// valid filters the matches when the expression alone is too broad
+	valid func(match []byte) bool
+}
+
+// LogRedactionOptions masks personal data before the events reach any sink.
+type LogRedactionOptions struct {
+	// Mode is on, off or auto, auto redacts only when the server runs in release mode
+	Mode string `yaml:"mode"`
+	// Fields are masked whole, a key also matches with a prefix, e.g. access_token
+	Fields []string `yaml:"fields"`
+	// Patterns are masked inside every string, the built in ones are email, jwt
+	// and card_number, anything else is a regular expression
+	Patterns []string `yaml:"patterns"`
+}
+
+// Enabled resolves the auto mode against the server mode.
+func (opt LogRedactionOptions) Enabled(serverMode string) bool {
+	switch opt.Mode {
+	case LogRedactionOn:
+		return true
+	case LogRedactionOff:
+		return false
+	default:
+		return serverMode == gin.ReleaseMode
+	}
+}
+
+// CompileLogRedactionPatterns checks the patterns of the options, it is used
+// by the config validation too.
+func CompileLogRedactionPatterns(patterns []string) error {
+	_, err := compileRedactionPatterns(patterns)
+	return err
+}
+
+func compileRedactionPatterns(patterns []string) ([]redactionPattern, error) {
+	if len(patterns) == 0 {
+		patterns = defaultRedactedPatterns
+	}
+
+	compiled := make([]redactionPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if builtin, ok := logRedactionPatterns[pattern]; ok {
+			compiled = append(compiled, builtin)
+			continue
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("log redaction pattern %q: %w", pattern, err)
+		}
+
+		compiled = append(compiled, redactionPattern{re: re})
+	}
+
+	return compiled, nil
+}
+
+// redactWriter rewrites the json of every event, so it sits in front of the
+// sinks and the console format never sees the raw values.
+type redactWriter struct {
+	writer   zerolog.LevelWriter
+	fields   []string
+	patterns []redactionPattern
+	// keyValues matches a sensitive key and its value in a text that is not
+	// json, e.g. password=secret or a json cut in the middle
+	keyValues *regexp.Regexp
+}
+
+func newRedactWriter(writer zerolog.LevelWriter, opt LogRedactionOptions) (*redactWriter, error) {
+	fields := opt.Fields
+	if len(fields) == 0 {
+		fields = defaultRedactedFields
+	}
+
+	patterns, err := compileRedactionPatterns(opt.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	lowered := make([]string, len(fields))
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		lowered[i] = strings.ToLower(field)
+		quoted[i] = regexp.QuoteMeta(lowered[i])
+	}
+
+	keyValues := regexp.MustCompile(`(?i)\b((?:[a-z0-9]+_)*(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"?|[^&,;\s}\]]*)`)
+
+	return &redactWriter{writer: writer, fields: lowered, patterns: patterns, keyValues: keyValues}, nil
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if _, err := w.writer.Write(w.redact(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *redactWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if _, err := w.writer.WriteLevel(level, w.redact(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// redact walks the json strings of the event. The value of a sensitive key is
+// replaced whole whatever its type, the patterns are masked inside the other
+// string values, so the event stays valid json whatever they match.
+func (w *redactWriter) redact(p []byte) []byte {
+	var out []byte
+	last, pos := 0, 0
+
+	for pos < len(p) {
+		m := jsonStringRegex.FindSubmatchIndex(p[pos:])
+		if m == nil {
+			break
+		}
+
+		for i := range m {
+			m[i] += pos
+		}
+
+		pos = m[1]
+		content := p[m[2]:m[3]]
+		isKey := m[5] > m[4]
+
+		var (
+			value    []byte
+			from, to int
+		)
+
+		if isKey {
+			if !w.isSensitiveField(string(content)) {
+				continue
+			}
+
+			from = skipJSONSpace(p, m[1])
+			to = jsonValueEnd(p, from)
+			if to == from {
+				continue
+			}
+
+			value = []byte(`"` + redactedLogValue + `"`)
+			pos = to
+		} else {
+			if value = w.maskPatterns(content); value == nil {
+				continue
+			}
+
+			from, to = m[2], m[3]
+		}
+
+		if out == nil {
+			out = make([]byte, 0, len(p))
+		}
+
+		out = append(out, p[last:from]...)
+		out = append(out, value...)
+		last = to
+	}
+
+	if out == nil {
+		return p
+	}
+
+	return append(out, p[last:]...)
+}
+
+func skipJSONSpace(p []byte, i int) int {
+	for i < len(p) && strings.IndexByte(" \t\r\n", p[i]) >= 0 {
+		i++
+	}
+
+	return i
+}
+
+// jsonValueEnd returns the end of the json value starting at i, a string, an
+// object or an array is followed to its closing character, anything else
+// runs until the next delimiter.
+func jsonValueEnd(p []byte, i int) int {
+	if i >= len(p) {
+		return i
+	}
+
+	depth := 0
+	inString := false
+
+	switch p[i] {
+	case '"', '{', '[':
+		for j := i; j < len(p); j++ {
+			switch c := p[j]; {
+			case inString && c == '\\':
+				j++
+			case inString && c == '"':
+				inString = false
+				if depth == 0 {
+					return j + 1
+				}
+			case inString:
+			case c == '"':
+				inString = true
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+
+		return len(p)
+	default:
+		j := i
+		for j < len(p) && strings.IndexByte(",}] \t\r\n", p[j]) < 0 {
+			j++
+		}
+
+		return j
+	}
+}
+
+// redactText masks the values of the sensitive keys and the patterns of a
+// text that cannot be walked as json.
+func (w *redactWriter) redactText(text []byte) []byte {
+	text = w.keyValues.ReplaceAll(text, []byte("${1}"+redactedLogValue))
+	if masked := w.maskPatterns(text); masked != nil {
+		return masked
+	}
+
+	return text
+}
+
+// maskPatterns returns nil when nothing matched.
+func (w *redactWriter) maskPatterns(content []byte) []byte {
+	masked := content
+	changed := false
+
+	for _, pattern := range w.patterns {
+		masked = pattern.re.ReplaceAllFunc(masked, func(match []byte) []byte {
+			if pattern.valid != nil && !pattern.valid(match) {
+				return match
+			}
+
+			changed = true
+
+			return []byte(redactedLogValue)
+		})
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return masked
+}
+
+func (w *redactWriter) isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+
+	return slices.ContainsFunc(w.fields, func(field string) bool {
+		return key == field || strings.HasSuffix(key, "_"+field)
+	})
+}
+
+func isLuhn(number []byte) bool {
+	sum := 0
+	double := false
+
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
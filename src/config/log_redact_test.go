@@ -0,0 +1,179 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactWriterRedact(t *testing.T) {
+	w, err := newRedactWriter(nil, LogRedactionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "no sensitive data",
+			in:   `{"level":"info","message":"ok","status":200}`,
+			want: `{"level":"info","message":"ok","status":200}`,
+		},
+		{
+			name: "string value",
+			in:   `{"password":"secret","user":"bob"}`,
+			want: `{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name: "prefixed key",
+			in:   `{"access_token":"abc"}`,
+			want: `{"access_token":"[REDACTED]"}`,
+		},
+		{
+			name: "key case",
+			in:   `{"Authorization":"Bearer abc"}`,
+			want: `{"Authorization":"[REDACTED]"}`,
+		},
+		{
+			name: "number value",
+			in:   `{"token":123456,"id":1}`,
+			want: `{"token":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "literal value",
+			in:   `{"password":true,"id":1}`,
+			want: `{"password":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "null value",
+			in:   `{"password":null}`,
+			want: `{"password":"[REDACTED]"}`,
+		},
+		{
+			name: "object value",
+			in:   `{"token":{"value":"abc","exp":1},"id":1}`,
+			want: `{"token":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "array value",
+			in:   `{"email":["a@example.com","b@example.com"],"id":1}`,
+			want: `{"email":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "nested brackets in strings",
+			in:   `{"token":{"v":"}]\"{"},"id":1}`,
+			want: `{"token":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "space after the colon",
+			in:   `{"password": "secret", "id": 1}`,
+			want: `{"password": "[REDACTED]", "id": 1}`,
+		},
+		{
+			name: "email pattern",
+			in:   `{"message":"sent to bob@example.com"}`,
+			want: `{"message":"sent to [REDACTED]"}`,
+		},
+		{
+			name: "jwt pattern",
+			in:   `{"message":"got eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig"}`,
+			want: `{"message":"got [REDACTED]"}`,
+		},
+		{
+			name: "card number",
+			in:   `{"message":"card 4111 1111 1111 1111"}`,
+			want: `{"message":"card [REDACTED]"}`,
+		},
+		{
+			name: "digits failing luhn",
+			in:   `{"message":"order 1234567890123"}`,
+			want: `{"message":"order 1234567890123"}`,
+		},
+		{
+			name: "keys are not masked by patterns",
+			in:   `{"bob@example.com":1}`,
+			want: `{"bob@example.com":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(w.redact([]byte(tt.in)))
+			if got != tt.want {
+				t.Errorf("redact(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+
+			if json.Valid([]byte(tt.in)) && !json.Valid([]byte(got)) {
+				t.Errorf("redact(%s) = %s, not valid json", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestRedactWriterRedactText(t *testing.T) {
+	w, err := newRedactWriter(nil, LogRedactionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "form values",
+			in:   `user=bob&password=secret&id=1`,
+			want: `user=bob&password=[REDACTED]&id=1`,
+		},
+		{
+			name: "truncated json",
+			in:   `{"user":"bob","password":"sec`,
+			want: `{"user":"bob","password":[REDACTED]`,
+		},
+		{
+			name: "prefixed key",
+			in:   `user_email: bob`,
+			want: `user_email: [REDACTED]`,
+		},
+		{
+			name: "pattern in free text",
+			in:   `contact bob@example.com`,
+			want: `contact [REDACTED]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(w.redactText([]byte(tt.in))); got != tt.want {
+				t.Errorf("redactText(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{number: "4111111111111111", want: true},
+		{number: "4111 1111 1111 1111", want: true},
+		{number: "4111-1111-1111-1111", want: true},
+		{number: "5500000000000004", want: true},
+		{number: "378282246310005", want: true},
+		{number: "4111111111111112", want: false},
+		{number: "1234567890123", want: false},
+		{number: "0", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := isLuhn([]byte(tt.number)); got != tt.want {
+				t.Errorf("isLuhn(%q) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"io"
+	"log/syslog"
 	"os"
-	"strconv"
 	"sync"
 	"time"
 
@@ -12,56 +13,247 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+const (
+	LogOutputStdout string = "stdout"
+	LogOutputStderr string = "stderr"
+	LogOutputFile   string = "file"
+	LogOutputSyslog string = "syslog"
+
+	LogFormatJSON    string = "json"
+	LogFormatConsole string = "console"
+
+	defaultSyslogTag string = "learngolang"
+)
+
+// LoggerOptions describes a single sink through Output, Format and the file
+// settings, Sinks replaces it when more than one destination is needed.
 type LoggerOptions struct {
-	Enabled    bool   `yaml:"enabled"`
+	// Enabled false keeps the development default, console logs on stdout
+	Enabled    bool                `yaml:"enabled"`
+	Level      string              `yaml:"level"`
+	Format     string              `yaml:"format"`
+	Output     string              `yaml:"output"`
+	Path       string              `yaml:"path"`
+	MaxSize    int                 `yaml:"max_size"`
+	MaxBackups int                 `yaml:"max_backups"`
+	MaxAge     int                 `yaml:"max_age"`
+	Compress   bool                `yaml:"compress"`
+	Sinks      []LogSinkOptions    `yaml:"sinks"`
+	Sampling   LogSamplingOptions  `yaml:"sampling"`
+	Redaction  LogRedactionOptions `yaml:"redaction"`
+}
+
+type LogSinkOptions struct {
+	Output string `yaml:"output"`
+	Format string `yaml:"format"`
+	// Level raises the minimum level of this sink above the logger level
 	Level      string `yaml:"level"`
-	Format     string `yaml:"format"`
-	Output     string `yaml:"output"`
 	Path       string `yaml:"path"`
 	MaxSize    int    `yaml:"max_size"`
 	MaxBackups int    `yaml:"max_backups"`
 	MaxAge     int    `yaml:"max_age"`
 	Compress   bool   `yaml:"compress"`
+	// Network and Address point to a remote syslog, the local one is used when empty
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
+}
+
+// LogSamplingOptions keeps the first Burst debug and info events of every
+// Period, then one out of Thereafter. Warnings and errors are never sampled.
+type LogSamplingOptions struct {
+	Enabled    bool          `yaml:"enabled"`
+	Burst      uint32        `yaml:"burst"`
+	Period     time.Duration `yaml:"period"`
+	Thereafter uint32        `yaml:"thereafter"`
+}
+
+// levelFilterWriter drops the events below the level of its sink.
+type levelFilterWriter struct {
+	writer zerolog.LevelWriter
+	level  zerolog.Level
+}
+
+func (w *levelFilterWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+func (w *levelFilterWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < w.level {
+		return len(p), nil
+	}
+
+	return w.writer.WriteLevel(level, p)
 }
 
 var onceLogger = sync.Once{}
 
-func InitLogger(opt LoggerOptions) zerolog.Logger {
+func InitLogger(opt LoggerOptions, serverMode string) zerolog.Logger {
 	var log zerolog.Logger
 
 	onceLogger.Do(func() {
 		zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 		zerolog.TimeFieldFormat = time.RFC3339
 
-		logLevel, err := strconv.Atoi(os.Getenv("LOG_LEVEL"))
+		level, err := parseLogLevel(opt.Level)
 		if err != nil {
-			logLevel = int(zerolog.DebugLevel)
+			level = zerolog.DebugLevel
 		}
 
-		var output io.Writer = zerolog.ConsoleWriter{
-			Out:        os.Stdout,
-			TimeFormat: time.RFC3339,
-		}
-
-		if opt.Enabled {
-			fileLogger := &lumberjack.Logger{
-				Filename:   opt.Path,
+		sinks := opt.Sinks
+		if len(sinks) == 0 {
+			sinks = []LogSinkOptions{{
+				Output:     opt.Output,
+				Format:     opt.Format,
+				Path:       opt.Path,
 				MaxSize:    opt.MaxSize,
 				MaxBackups: opt.MaxBackups,
 				MaxAge:     opt.MaxAge,
 				Compress:   opt.Compress,
+			}}
+		}
+
+		if !opt.Enabled {
+			sinks = []LogSinkOptions{{Output: LogOutputStdout, Format: LogFormatConsole}}
+		}
+
+		writers := make([]io.Writer, 0, len(sinks))
+		sinkErrs := make([]error, 0)
+		for _, sink := range sinks {
+			writer, err := newLogSink(sink)
+			if err != nil {
+				// a broken sink must not leave the app without logs
+				sinkErrs = append(sinkErrs, err)
+				writer = zerolog.MultiLevelWriter(os.Stderr)
 			}
 
-			output = zerolog.MultiLevelWriter(os.Stderr, fileLogger)
+			writers = append(writers, writer)
 		}
 
-		log = zerolog.New(output).
-			Level(zerolog.Level(logLevel)).
+		// the level is kept outside of the loggers so it can be changed at runtime
+		// from the admin server, see log_level.go
+		SetLogLevel(level)
+		logWriter = zerolog.MultiLevelWriter(writers...)
+
+		redactor, redactErr := newRedactWriter(logWriter, opt.Redaction)
+		if redactErr != nil {
+			// personal data must not leak because of a typo, keep the built in patterns
+			redactor, _ = newRedactWriter(logWriter, LogRedactionOptions{Fields: opt.Redaction.Fields})
+		}
+
+		bodyRedactor = redactor
+		if opt.Redaction.Enabled(serverMode) {
+			logWriter = redactor
+		}
+
+		log = zerolog.New(&componentLevelWriter{writer: logWriter, component: logComponentBase}).
 			With().
 			Timestamp().
 			Caller().
 			Logger()
+
+		if opt.Sampling.Enabled {
+			sampler := &zerolog.BurstSampler{
+				Burst:       opt.Sampling.Burst,
+				Period:      opt.Sampling.Period,
+				NextSampler: &zerolog.BasicSampler{N: opt.Sampling.Thereafter},
+			}
+
+			log = log.Sample(zerolog.LevelSampler{
+				TraceSampler: sampler,
+				DebugSampler: sampler,
+				InfoSampler:  sampler,
+			})
+		}
+
+		for _, err := range sinkErrs {
+			log.Error().Err(err).Msg("Log sink unavailable, writing to stderr instead")
+		}
+
+		if redactErr != nil {
+			log.Error().Err(redactErr).Msg("Invalid log redaction, using the default patterns")
+		}
 	})
 
 	return log
 }
+
+func newLogSink(opt LogSinkOptions) (zerolog.LevelWriter, error) {
+	var writer io.Writer
+	color := false
+
+	switch opt.Output {
+	case LogOutputStdout, "":
+		writer, color = os.Stdout, true
+	case LogOutputStderr:
+		writer, color = os.Stderr, true
+	case LogOutputFile:
+		writer = &lumberjack.Logger{
+			Filename:   opt.Path,
+			MaxSize:    opt.MaxSize,
+			MaxBackups: opt.MaxBackups,
+			MaxAge:     opt.MaxAge,
+			Compress:   opt.Compress,
+		}
+	case LogOutputSyslog:
+		tag := opt.Tag
+		if tag == "" {
+			tag = defaultSyslogTag
+		}
+
+		syslogWriter, err := syslog.Dial(opt.Network, opt.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+		if err != nil {
+			return nil, fmt.Errorf("syslog %s: %w", opt.Address, err)
+		}
+
+		// syslog carries the severity itself, so events go through its level writer
+		writer = zerolog.SyslogLevelWriter(syslogWriter)
+	default:
+		return nil, fmt.Errorf("unknown log output %q", opt.Output)
+	}
+
+	if opt.Format == LogFormatConsole {
+		writer = zerolog.ConsoleWriter{
+			Out:        writer,
+			TimeFormat: time.RFC3339,
+			NoColor:    !color,
+		}
+	}
+
+	levelWriter, ok := writer.(zerolog.LevelWriter)
+	if !ok {
+		levelWriter = zerolog.MultiLevelWriter(writer)
+	}
+
+	if opt.Level == "" {
+		return levelWriter, nil
+	}
+
+	level, err := zerolog.ParseLevel(opt.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelFilterWriter{writer: levelWriter, level: level}, nil
+}
+
+// ReloadLogger applies the runtime settings of the logger, it is called on config reload.
+func ReloadLogger(log zerolog.Logger, opt LoggerOptions) {
+	level, err := parseLogLevel(opt.Level)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid log level, keeping the current one")
+		return
+	}
+
+	SetLogLevel(level)
+}
+
+// parseLogLevel is zerolog.ParseLevel with an empty level meaning debug, where
+// zerolog would return NoLevel and drop every event.
+func parseLogLevel(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.DebugLevel, nil
+	}
+
+	return zerolog.ParseLevel(level)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type MessagesOptions struct {
+	// Path is the directory of the catalogs, one <locale>.yaml or .json per language
+	Path string `yaml:"path"`
+	// DefaultLanguage answers the clients asking for no language or for one without a catalog
+	DefaultLanguage string `yaml:"default_language"`
+}
+
+func InitMessages(log zerolog.Logger, opt MessagesOptions) error {
+	locales, err := exception.ReadCatalogs(opt.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := exception.SetCatalogs(locales, opt.DefaultLanguage); err != nil {
+		return err
+	}
+
+	log.Info().Strs("languages", exception.Languages()).Msg("Message catalogs loaded successfully")
+
+	return nil
+}
+
+// RequestLanguage picks the catalog of the response, x-app-lang first then
+// the Accept-Language preferences.
+func RequestLanguage(c *gin.Context) string {
+	tags := make([]string, 0)
+	if lang := c.GetHeader(preference.APP_LANG); lang != "" {
+		tags = append(tags, lang)
+	}
+
+	tags = append(tags, exception.ParseAcceptLanguage(c.GetHeader("Accept-Language"))...)
+
+	return exception.MatchLanguage(tags...)
+}
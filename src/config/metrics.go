@@ -0,0 +1,257 @@
+package config
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultMetricsPath      string = "/metrics"
+	defaultMetricsNamespace string = "app"
+
+	MetricsCacheHit  string = "hit"
+	MetricsCacheMiss string = "miss"
+)
+
+var onceMetrics = &sync.Once{}
+
+type MetricsOptions struct {
+	Enabled   bool   `yaml:"enabled"`
+	Path      string `yaml:"path"`
+	Namespace string `yaml:"namespace"`
+}
+
+// Metrics holds every prometheus collector of the app. All methods are safe to
+// call on a nil *Metrics, which is what InitMetrics returns when disabled.
+type Metrics struct {
+	opt          MetricsOptions
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+	panics       prometheus.Counter
+	cacheResults *prometheus.CounterVec
+	jobRuns      *prometheus.CounterVec
+	jobDuration  *prometheus.HistogramVec
+}
+
+func InitMetrics(log zerolog.Logger, opt MetricsOptions) *Metrics {
+	var m *Metrics
+
+	if !opt.Enabled {
+		return nil
+	}
+
+	onceMetrics.Do(func() {
+		if opt.Path == "" {
+			opt.Path = defaultMetricsPath
+		}
+
+		if opt.Namespace == "" {
+			opt.Namespace = defaultMetricsNamespace
+		}
+
+		m = &Metrics{
+			opt:      opt,
+			registry: prometheus.NewRegistry(),
+			httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "http",
+				Name:      "requests_total",
+				Help:      "Total number of HTTP requests by route, method and status code.",
+			}, []string{"method", "route", "status"}),
+			httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "http",
+				Name:      "request_duration_seconds",
+				Help:      "HTTP request latency by route, method and status code.",
+				Buckets:   prometheus.DefBuckets,
+			}, []string{"method", "route", "status"}),
+			httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "http",
+				Name:      "requests_in_flight",
+				Help:      "Number of HTTP requests currently being served.",
+			}),
+			panics: prometheus.NewCounter(prometheus.CounterOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "http",
+				Name:      "panics_total",
+				Help:      "Total number of panics recovered in HTTP handlers.",
+			}),
+			cacheResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "cache",
+				Name:      "requests_total",
+				Help:      "Total number of cache lookups by cache name and result.",
+			}, []string{"cache", "result"}),
+			jobRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "scheduler",
+				Name:      "job_runs_total",
+				Help:      "Total number of scheduler job runs by job and status.",
+			}, []string{"job", "status"}),
+			jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: opt.Namespace,
+				Subsystem: "scheduler",
+				Name:      "job_duration_seconds",
+				Help:      "Scheduler job run duration by job.",
+				Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 300},
+			}, []string{"job"}),
+		}
+
+		m.registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			m.httpRequests,
+			m.httpDuration,
+			m.httpInFlight,
+			m.panics,
+			m.cacheResults,
+			m.jobRuns,
+			m.jobDuration,
+		)
+
+		log.Debug().Str("path", opt.Path).Msg("Metrics initialized")
+	})
+
+	return m
+}
+
+func (m *Metrics) Path() string {
+	if m == nil {
+		return ""
+	}
+
+	return m.opt.Path
+}
+
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) RegisterDB(name string, db *sql.DB) {
+	if m == nil || db == nil {
+		return
+	}
+
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+func (m *Metrics) RegisterRedis(name string, client *redis.Client) {
+	if m == nil || client == nil {
+		return
+	}
+
+	m.registry.MustRegister(newRedisPoolCollector(m.opt.Namespace, name, client))
+}
+
+func (m *Metrics) HTTPRequestStarted() {
+	if m == nil {
+		return
+	}
+
+	m.httpInFlight.Inc()
+}
+
+func (m *Metrics) HTTPRequestFinished(method string, route string, status int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	statusStr := strconv.Itoa(status)
+
+	m.httpInFlight.Dec()
+	m.httpRequests.WithLabelValues(method, route, statusStr).Inc()
+	m.httpDuration.WithLabelValues(method, route, statusStr).Observe(latency.Seconds())
+}
+
+func (m *Metrics) IncPanic() {
+	if m == nil {
+		return
+	}
+
+	m.panics.Inc()
+}
+
+func (m *Metrics) ObserveCache(cache string, result string) {
+	if m == nil {
+		return
+	}
+
+	m.cacheResults.WithLabelValues(cache, result).Inc()
+}
+
+func (m *Metrics) ObserveJob(job string, err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+
+	m.jobRuns.WithLabelValues(job, status).Inc()
+	m.jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+type redisPoolCollector struct {
+	client     *redis.Client
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newRedisPoolCollector(namespace string, name string, client *redis.Client) *redisPoolCollector {
+	labels := prometheus.Labels{"client": name}
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", metric), help, nil, labels)
+	}
+
+	return &redisPoolCollector{
+		client:     client,
+		hits:       desc("hits_total", "Number of times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Number of times a free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "Number of times a wait for a connection timed out."),
+		totalConns: desc("total_conns", "Number of total connections in the pool."),
+		idleConns:  desc("idle_conns", "Number of idle connections in the pool."),
+		staleConns: desc("stale_conns_total", "Number of stale connections removed from the pool."),
+	}
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
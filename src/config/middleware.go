@@ -4,23 +4,33 @@ import (
 	"context"
 	"net/http"
 	"slices"
-	"strings"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	exception "learngolang/src/errors"
 	"learngolang/src/preference"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	"github.com/rs/xid"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var onceMiddlewre = &sync.Once{}
 
 type Middleware interface {
 	Handler() gin.HandlerFunc
+	Recovery() gin.HandlerFunc
+	Metrics() gin.HandlerFunc
 	CORS() gin.HandlerFunc
+	SecurityHeaders() gin.HandlerFunc
+	Idempotency() gin.HandlerFunc
+	AbortWithError(c *gin.Context, err error)
+	UseProblemJSON(c *gin.Context) bool
+	CompileError(c *gin.Context, err error) (int, exception.AppError)
+	Reload(opt MiddlewareOptions)
 	// Limiter(command string, limit int) gin.HandlerFunc
 	// JWT() gin.HandlerFunc
 	// KC() gin.HandlerFunc
@@ -33,7 +43,33 @@ type middleware struct {
 	// deadline  int64
 	// shaScript map[string]string
 	// period    time.Duration
-	rdb *redis.Client
+	rdb      *redis.Client
+	settings atomic.Pointer[middlewareSettings]
+	metrics  *Metrics
+	// serverMode resolves the auto exposure of the debug errors
+	serverMode string
+}
+
+// middlewareSettings is swapped as a whole on reload, so a request never sees
+// half of an old and half of a new config.
+type middlewareSettings struct {
+	cors            *corsPolicy
+	securityHeaders map[string]string
+	idempotency     IdempotencyOptions
+	accessLog       AccessLogOptions
+	errorFormat     string
+	debugErrors     DebugErrorOptions
+}
+
+type MiddlewareOptions struct {
+	CORS            CORSOptions            `yaml:"cors"`
+	SecurityHeaders SecurityHeadersOptions `yaml:"security_headers"`
+	Idempotency     IdempotencyOptions     `yaml:"idempotency"`
+	AccessLog       AccessLogOptions       `yaml:"access_log"`
+	// ErrorFormat is envelope or problem, an Accept of application/problem+json
+	// selects problem for a single request
+	ErrorFormat string            `yaml:"error_format"`
+	DebugErrors DebugErrorOptions `yaml:"debug_errors"`
 }
 
 type Options struct {
@@ -45,108 +81,159 @@ type LimiterOptions struct {
 	Limit   int
 }
 
-func InitMiddleware(log zerolog.Logger, auth Auth, rdb *redis.Client) Middleware {
+func InitMiddleware(log zerolog.Logger, opt MiddlewareOptions, serverMode string, auth Auth, rdb *redis.Client, metrics *Metrics) Middleware {
 	var m *middleware
 
 	onceMiddlewre.Do(func() {
 		m = &middleware{
-			log:  log,
-			auth: auth,
-			rdb:  rdb,
+			log:     WithLogComponent(log, LogComponentMiddleware),
+			auth:    auth,
+			rdb:     rdb,
+			metrics: metrics,
+
+			serverMode: serverMode,
 		}
+
+		m.Reload(opt)
 	})
 
 	return m
 }
 
+// Reload applies the options to the next requests, the handlers already
+// registered on the routers keep working.
+func (mw *middleware) Reload(opt MiddlewareOptions) {
+	if opt.CORS.AllowCredentials && slices.Contains(opt.CORS.AllowedOrigins, "*") {
+		mw.log.Warn().Msg("CORS credentials are not allowed with the * origin, allow_credentials is ignored")
+	}
+
+	mw.settings.Store(&middlewareSettings{
+		cors:            newCORSPolicy(opt.CORS),
+		securityHeaders: newSecurityHeaders(opt.SecurityHeaders),
+		idempotency:     withIdempotencyDefaults(opt.Idempotency),
+		accessLog:       withAccessLogDefaults(opt.AccessLog),
+		errorFormat:     opt.ErrorFormat,
+		debugErrors:     withDebugErrorDefaults(opt.DebugErrors, mw.serverMode),
+	})
+}
+
 func (mw *middleware) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		path := c.Request.URL.Path
-
-		if !strings.HasPrefix(path, "/swagger/") { // skip logging swagger request
-			start := time.Now()
-
-			ctx := c.Request.Context()
-			ctx = mw.attachReqID(ctx)
-			ctx = mw.attachLogger(ctx)
-
-			raw := c.Request.URL.RawQuery
-			if raw != "" {
-				path = path + "?" + raw
-			}
-
-			mw.log.Info().
-				Str(preference.EVENT, "START").
-				Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), mw.getRequestID(ctx)).
-				Str(preference.METHOD, c.Request.Method).
-				Str(preference.URL, path).
-				Str(preference.USER_AGENT, c.Request.UserAgent()).
-				Str(preference.ADDR, c.Request.Host).
-				Send()
-
-			// Process request
-			c.Request = c.Request.WithContext(ctx)
+		start := time.Now()
+		opt := mw.settings.Load().accessLog
+
+		ctx := c.Request.Context()
+		ctx = mw.attachReqID(ctx, c.Request.Header)
+
+		mw.attachClientIdentity(c)
+
+		// the admin token authenticates an admin on the public API too, its
+		// error responses keep their debug output
+		if isAdminToken(bearerToken(c)) {
+			c.Set(preference.CONTEXT_KEY_ADMIN, true)
+		}
+
+		if mw.isDebugLogAllowed(c) {
+			ctx = context.WithValue(ctx, preference.CONTEXT_KEY_DEBUG_LOG, true)
+			endDebugRequest := beginDebugRequest()
+			defer endDebugRequest()
+		}
+
+		// skipped paths get neither a span nor an access log
+		skip := opt.skip(c.Request.URL.Path)
+
+		// the span goes first so the request logger carries its trace ID
+		var span trace.Span
+		if !skip {
+			ctx, span = mw.startRequestSpan(ctx, c)
+		}
+
+		ctx = mw.attachLogger(ctx)
+
+		c.Header(preference.HEADER_REQUEST_ID, mw.getRequestID(ctx))
+		c.Request = c.Request.WithContext(ctx)
+
+		if skip {
 			c.Next()
+			return
+		}
 
-			// Fill the params
-			param := gin.LogFormatterParams{}
+		recorder := newAccessRecorder(c, opt)
 
-			param.TimeStamp = time.Now() // Stop timer
-			param.Latency = param.TimeStamp.Sub(start)
-			if param.Latency > time.Minute {
-				param.Latency = param.Latency.Truncate(time.Second)
-			}
+		// Process request
+		c.Next()
 
-			param.StatusCode = c.Writer.Status()
+		mw.endRequestSpan(c, span)
+		mw.logAccess(c, recorder, time.Since(start))
+	}
+}
 
-			mw.log.Info().
-				Str(preference.EVENT, "END").
-				Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), mw.getRequestID(ctx)).
-				Str(preference.LATENCY, param.Latency.String()).
-				Int(preference.STATUS, param.StatusCode).
-				Send()
+func (mw *middleware) Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mw.metrics == nil {
+			c.Next()
+			return
 		}
+
+		start := time.Now()
+		mw.metrics.HTTPRequestStarted()
+
+		c.Next()
+
+		// use the route template to keep the label cardinality bounded
+		mw.metrics.HTTPRequestFinished(c.Request.Method, routeTemplate(c), c.Writer.Status(), time.Since(start))
 	}
 }
 
-func (mw *middleware) attachReqID(ctx context.Context) context.Context {
-	return context.WithValue(ctx, preference.CONTEXT_KEY_REQUEST_ID, xid.New().String())
+func (mw *middleware) attachReqID(ctx context.Context, header http.Header) context.Context {
+	return NewRequestContext(ctx, header)
 }
 
-func (mw *middleware) attachLogger(ctx context.Context) context.Context {
-	return mw.log.With().Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), mw.getRequestID(ctx)).Logger().WithContext(ctx)
+// attachClientIdentity makes the common name of a verified client certificate
+// the request user, requests without one stay anonymous here.
+func (mw *middleware) attachClientIdentity(c *gin.Context) {
+	identity := ClientIdentityFromTLS(c.Request.TLS)
+	if identity == nil {
+		return
+	}
+
+	c.Set(preference.CONTEXT_KEY_USER, identity.CommonName)
+	c.Set(preference.CONTEXT_KEY_CLIENT_IDENTITY, identity)
 }
 
-func (mw *middleware) getRequestID(ctx context.Context) string {
-	reqID := ctx.Value(preference.CONTEXT_KEY_REQUEST_ID)
+func (mw *middleware) attachLogger(ctx context.Context) context.Context {
+	logCtx := mw.log.With().Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), mw.getRequestID(ctx))
+	if tp, ok := TraceParentFromContext(ctx); ok {
+		logCtx = logCtx.Str(preference.TRACE_ID, tp.TraceID)
+	}
 
-	if ret, ok := reqID.(string); ok {
-		return ret
+	// the request logger is not part of the middleware component, the handlers use it too
+	log := WithLogComponent(logCtx.Logger(), logComponentBase)
+	if isDebugLogRequest(ctx) && logWriter != nil {
+		log = logCtx.Logger().Output(logWriter).Level(zerolog.DebugLevel)
 	}
 
-	return ""
+	return log.WithContext(ctx)
 }
 
-func (mw *middleware) CORS() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		strMethods := []string{"GET", "POST"}
-
-		c.Header("Access-Control-Allow-Headers:", "*")
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", strings.Join(strMethods, ", "))
-		c.Header("X-Frame-Options", "DENY")
-		c.Header("Content-Security-Policy", "default-src 'self'; connect-src *; font-src *; script-src-elem * 'unsafe-inline'; img-src * data:; style-src * 'unsafe-inline';")
-		c.Header("X-XSS-Protection", "1; mode=block")
-		c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
-		c.Header("Referrer-Policy", "strict-origin")
-		c.Header("X-Content-Type-Options", "nosniff")
-		c.Header("Permissions-Policy", "geolocation=(),midi=(),sync-xhr=(),microphone=(),camera=(),magnetometer=(),gyroscope=(),fullscreen=(self),payment=()")
-
-		if !slices.Contains(strMethods, c.Request.Method) {
-			c.AbortWithStatus(http.StatusMethodNotAllowed)
-			return
-		}
+// isDebugLogAllowed reports whether an authenticated admin asks for debug logs,
+// the header of anyone else is logged and otherwise ignored.
+func (mw *middleware) isDebugLogAllowed(c *gin.Context) bool {
+	provided := c.GetHeader(preference.HEADER_DEBUG_LOG)
+	if provided == "" {
+		return false
+	}
 
-		c.Next()
+	if !c.GetBool(preference.CONTEXT_KEY_ADMIN) {
+		mw.log.Warn().Str(preference.CLIENT_IP, c.ClientIP()).Msg("debug_log_rejected")
+		return false
 	}
+
+	enabled, err := strconv.ParseBool(provided)
+
+	return err == nil && enabled
+}
+
+func (mw *middleware) getRequestID(ctx context.Context) string {
+	return RequestIDFromContext(ctx)
 }
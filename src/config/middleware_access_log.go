@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const defaultAccessLogMaxBodySize = 4096
+
+var defaultAccessLogSkipPaths = []string{"/swagger/"}
+
+type AccessLogOptions struct {
+	// SkipPaths are path prefixes without access log, /swagger/ when empty
+	SkipPaths []string `yaml:"skip_paths"`
+	// SlowThreshold flags the slower requests, 0 disables it
+	SlowThreshold time.Duration `yaml:"slow_threshold"`
+	// BodySampleRate is the share of slow requests logged with their bodies, from 0 to 1
+	BodySampleRate float64 `yaml:"body_sample_rate"`
+	MaxBodySize    int     `yaml:"max_body_size"`
+}
+
+func withAccessLogDefaults(opt AccessLogOptions) AccessLogOptions {
+	if len(opt.SkipPaths) == 0 {
+		opt.SkipPaths = defaultAccessLogSkipPaths
+	}
+
+	if opt.MaxBodySize <= 0 {
+		opt.MaxBodySize = defaultAccessLogMaxBodySize
+	}
+
+	return opt
+}
+
+func (opt AccessLogOptions) skip(path string) bool {
+	for _, prefix := range opt.SkipPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestBodyReader counts the bytes read by the handlers, so chunked bodies
+// are measured too, and keeps the first ones when the body is sampled.
+type requestBodyReader struct {
+	io.ReadCloser
+	size  int64
+	body  *bytes.Buffer
+	limit int
+}
+
+func (r *requestBodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += int64(n)
+
+	if r.body != nil && r.body.Len() < r.limit {
+		r.body.Write(p[:min(n, r.limit-r.body.Len())])
+	}
+
+	return n, err
+}
+
+// accessRecorder collects what the access log needs while the request is served.
+type accessRecorder struct {
+	opt     AccessLogOptions
+	request *requestBodyReader
+	writer  *bodyCaptureWriter
+}
+
+func newAccessRecorder(c *gin.Context, opt AccessLogOptions) *accessRecorder {
+	// the bodies are only known once the request is slow, so the sample is
+	// drawn upfront and only the sampled requests pay for the capture
+	sampled := opt.SlowThreshold > 0 && opt.BodySampleRate > 0 && rand.Float64() < opt.BodySampleRate
+
+	recorder := &accessRecorder{opt: opt}
+
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		recorder.request = &requestBodyReader{ReadCloser: c.Request.Body, limit: opt.MaxBodySize}
+		c.Request.Body = recorder.request
+	}
+
+	if sampled {
+		if recorder.request != nil {
+			recorder.request.body = &bytes.Buffer{}
+		}
+
+		recorder.writer = &bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, limit: opt.MaxBodySize}
+		c.Writer = recorder.writer
+	}
+
+	return recorder
+}
+
+func (r *accessRecorder) bytesIn() int64 {
+	if r.request == nil {
+		return 0
+	}
+
+	return r.request.size
+}
+
+func (mw *middleware) logAccess(c *gin.Context, recorder *accessRecorder, latency time.Duration) {
+	status := c.Writer.Status()
+	slow := recorder.opt.SlowThreshold > 0 && latency >= recorder.opt.SlowThreshold
+
+	log := ComponentLogger(c.Request.Context(), LogComponentMiddleware)
+
+	var event *zerolog.Event
+	switch {
+	case status >= http.StatusInternalServerError:
+		event = log.Error()
+	case slow:
+		event = log.Warn()
+	default:
+		event = log.Info()
+	}
+
+	event = event.
+		Str(preference.EVENT, "ACCESS").
+		Str(preference.METHOD, c.Request.Method).
+		Str(preference.ROUTE, routeTemplate(c)).
+		Int(preference.STATUS, status).
+		Str(preference.LATENCY, latency.String()).
+		Str(preference.CLIENT_IP, c.ClientIP()).
+		Int64(preference.BYTES_IN, recorder.bytesIn()).
+		Int(preference.BYTES_OUT, max(c.Writer.Size(), 0)).
+		Str(preference.USER_AGENT, c.Request.UserAgent())
+
+	if user := c.GetString(preference.CONTEXT_KEY_USER); user != "" {
+		event = event.Str(preference.USER, user)
+	}
+
+	if err := c.Errors.Last(); err != nil {
+		_, appErr := exception.Compile(exception.COMMON, err.Err, preference.LANG_EN, false)
+		event = event.Uint16(preference.ERROR_CODE, uint16(appErr.Code))
+	}
+
+	if slow {
+		event = event.Bool(preference.SLOW, true)
+
+		if recorder.writer != nil {
+			if recorder.request != nil {
+				event = event.RawJSON(preference.REQUEST_BODY, redactLogBody(recorder.request.body.Bytes(), recorder.request.size > int64(recorder.request.body.Len())))
+			}
+
+			event = event.RawJSON(preference.RESPONSE_BODY, redactLogBody(recorder.writer.body.Bytes(), recorder.writer.truncated()))
+		}
+	}
+
+	event.Send()
+}
+
+// routeTemplate keeps ids out of the logs and the metric labels.
+func routeTemplate(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+
+	return "unmatched"
+}
+
+// redactLogBody logs a json body as json so its fields are redacted by name,
+// anything else, a truncated json included, is logged as a string masked
+// key by key since the json walk would only see a single value.
+func redactLogBody(body []byte, truncated bool) []byte {
+	if !truncated && json.Valid(body) {
+		return bodyRedactor.redact(body)
+	}
+
+	text, _ := json.Marshal(string(bodyRedactor.redactText(body)))
+
+	return text
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "Cache-Control", "Idempotency-Key", "X-App-Lang"}
+)
+
+type CORSOptions struct {
+	// Enabled is true when the config leaves it out, any origin is then allowed
+	Enabled          bool          `yaml:"enabled"`
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// corsPolicy is CORSOptions with the defaults applied and the headers joined
+// once, it is rebuilt on every config reload.
+type corsPolicy struct {
+	opt             CORSOptions
+	allowAllHeaders bool
+	allowedMethods  string
+	allowedHeaders  string
+	exposedHeaders  string
+	maxAge          string
+}
+
+func newCORSPolicy(opt CORSOptions) *corsPolicy {
+	methods := opt.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+
+	if len(opt.AllowedHeaders) == 0 {
+		opt.AllowedHeaders = defaultCORSHeaders
+	}
+
+	// browsers refuse credentials with a wildcard origin, echoing any origin
+	// instead would hand the credentials to every site
+	if opt.AllowCredentials && slices.Contains(opt.AllowedOrigins, "*") {
+		opt.AllowCredentials = false
+	}
+
+	opt.AllowedMethods = make([]string, len(methods))
+	for i, method := range methods {
+		opt.AllowedMethods[i] = strings.ToUpper(method)
+	}
+
+	return &corsPolicy{
+		opt:             opt,
+		allowAllHeaders: slices.Contains(opt.AllowedHeaders, "*"),
+		allowedMethods:  strings.Join(opt.AllowedMethods, ", "),
+		allowedHeaders:  strings.Join(opt.AllowedHeaders, ", "),
+		exposedHeaders:  strings.Join(opt.ExposedHeaders, ", "),
+		maxAge:          strconv.Itoa(int(opt.MaxAge.Seconds())),
+	}
+}
+
+func (mw *middleware) CORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := mw.settings.Load().cors
+		opt := policy.opt
+
+		origin := c.GetHeader("Origin")
+		if !opt.Enabled || origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+
+		isPreflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !isOriginAllowed(opt.AllowedOrigins, origin) {
+			if isPreflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			// let the browser enforce the policy, the request itself is still served
+			c.Next()
+			return
+		}
+
+		// the policy never combines the * origin with credentials
+		allowOrigin := origin
+		if slices.Contains(opt.AllowedOrigins, "*") {
+			allowOrigin = "*"
+		}
+
+		c.Header("Access-Control-Allow-Origin", allowOrigin)
+		if opt.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !isPreflight {
+			if policy.exposedHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		if !slices.Contains(opt.AllowedMethods, strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))) {
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", policy.allowedMethods)
+
+		if policy.allowAllHeaders {
+			if reqHeaders := c.GetHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", reqHeaders)
+			}
+		} else {
+			c.Header("Access-Control-Allow-Headers", policy.allowedHeaders)
+		}
+
+		if opt.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", policy.maxAge)
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// isOriginAllowed matches the origin against the configured list, every entry
+// may contain a single `*` wildcard, e.g. `https://*.example.com` or `*`.
+func isOriginAllowed(allowedOrigins []string, origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range allowedOrigins {
+		allowed = strings.ToLower(allowed)
+
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		prefix, suffix, found := strings.Cut(allowed, "*")
+		if found && len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
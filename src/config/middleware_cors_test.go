@@ -0,0 +1,90 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func TestIsOriginAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "any", allowed: []string{"*"}, origin: "https://example.com", want: true},
+		{name: "exact", allowed: []string{"https://example.com"}, origin: "https://example.com", want: true},
+		{name: "case", allowed: []string{"https://Example.com"}, origin: "https://EXAMPLE.com", want: true},
+		{name: "other host", allowed: []string{"https://example.com"}, origin: "https://evil.com", want: false},
+		{name: "other scheme", allowed: []string{"https://example.com"}, origin: "http://example.com", want: false},
+		{name: "other port", allowed: []string{"https://example.com"}, origin: "https://example.com:8443", want: false},
+		{name: "subdomain wildcard", allowed: []string{"https://*.example.com"}, origin: "https://api.example.com", want: true},
+		{name: "wildcard needs a subdomain", allowed: []string{"https://*.example.com"}, origin: "https://example.com", want: false},
+		{name: "wildcard suffix", allowed: []string{"https://*.example.com"}, origin: "https://api.example.com.evil.com", want: false},
+		{name: "wildcard prefix and suffix overlap", allowed: []string{"https://a*a.com"}, origin: "https://a.com", want: false},
+		{name: "second entry", allowed: []string{"https://a.com", "https://b.com"}, origin: "https://b.com", want: true},
+		{name: "empty list", allowed: nil, origin: "https://example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOriginAllowed(tt.allowed, tt.origin); got != tt.want {
+				t.Errorf("isOriginAllowed(%v, %q) = %v, want %v", tt.allowed, tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSWildcardCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		opt             CORSOptions
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{
+			name:       "wildcard",
+			opt:        CORSOptions{Enabled: true, AllowedOrigins: []string{"*"}},
+			wantOrigin: "*",
+		},
+		{
+			name:       "wildcard drops credentials",
+			opt:        CORSOptions{Enabled: true, AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			wantOrigin: "*",
+		},
+		{
+			name:            "listed origin keeps credentials",
+			opt:             CORSOptions{Enabled: true, AllowedOrigins: []string{"https://evil.com"}, AllowCredentials: true},
+			wantOrigin:      "https://evil.com",
+			wantCredentials: "true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := &middleware{log: zerolog.Nop()}
+			mw.Reload(MiddlewareOptions{CORS: tt.opt})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/users", nil)
+			c.Request.Header.Set("Origin", "https://evil.com")
+
+			mw.CORS()(c)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
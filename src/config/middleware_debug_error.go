@@ -0,0 +1,143 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DebugErrorExposeAuto       string = "auto"
+	DebugErrorExposeAll        string = "all"
+	DebugErrorExposeAuthorized string = "authorized"
+	DebugErrorExposeNone       string = "none"
+
+	DefaultDebugErrorMaxTTL = 15 * time.Minute
+)
+
+// DebugErrorOptions decides who gets the internal error chain in the error
+// responses, the others get the request ID and the chain is logged under it.
+type DebugErrorOptions struct {
+	// Expose is all, authorized, none or auto, auto is all outside release mode
+	// and authorized in release mode
+	Expose string `yaml:"expose"`
+	// SigningKey signs the X-Debug-Error header, the header is ignored without it
+	SigningKey string `yaml:"signing_key" secret:"true"`
+	// MaxTTL caps how far in the future a signature may expire
+	MaxTTL time.Duration `yaml:"max_ttl"`
+}
+
+func withDebugErrorDefaults(opt DebugErrorOptions, serverMode string) DebugErrorOptions {
+	if opt.Expose == "" || opt.Expose == DebugErrorExposeAuto {
+		opt.Expose = DebugErrorExposeAuthorized
+		if serverMode != gin.ReleaseMode {
+			opt.Expose = DebugErrorExposeAll
+		}
+	}
+
+	if opt.MaxTTL <= 0 {
+		opt.MaxTTL = DefaultDebugErrorMaxTTL
+	}
+
+	return opt
+}
+
+// SignDebugError returns the X-Debug-Error value allowing the debug output of
+// the requests to method and path until expires.
+func SignDebugError(key string, method string, path string, expires time.Time) string {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+
+	return unix + "." + debugErrorSignature(key, method, path, unix)
+}
+
+func debugErrorSignature(key string, method string, path string, expires string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(method + " " + path + "\n" + expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CompileError turns err into the status and the body of the error response,
+// in the language of the request, with the debug output only when allowed.
+func (mw *middleware) CompileError(c *gin.Context, err error) (int, exception.AppError) {
+	lang := RequestLanguage(c)
+
+	statusCode, appErr := exception.Compile(exception.COMMON, err, lang, true)
+	appErr.Errors = ValidationErrors(err, lang)
+
+	if appErr.DebugError != nil && !mw.isDebugErrorAllowed(c) {
+		log := ComponentLogger(c.Request.Context(), LogComponentMiddleware)
+
+		event := log.Info()
+		if statusCode >= http.StatusInternalServerError {
+			event = log.Error()
+		}
+
+		// the client only gets the request ID, which finds this event
+		event.
+			Int(preference.STATUS, statusCode).
+			Uint16(preference.ERROR_CODE, uint16(appErr.Code)).
+			Str(preference.ERROR_DEBUG, *appErr.DebugError).
+			Msg("error_debug_withheld")
+
+		appErr.DebugError = nil
+	}
+
+	return statusCode, appErr
+}
+
+func (mw *middleware) isDebugErrorAllowed(c *gin.Context) bool {
+	opt := mw.settings.Load().debugErrors
+
+	switch opt.Expose {
+	case DebugErrorExposeAll:
+		return true
+	case DebugErrorExposeNone:
+		return false
+	}
+
+	if c.GetBool(preference.CONTEXT_KEY_ADMIN) {
+		return true
+	}
+
+	provided := c.GetHeader(preference.HEADER_DEBUG_ERROR)
+	if provided == "" || opt.SigningKey == "" {
+		return false
+	}
+
+	if !isDebugErrorSigned(opt, c.Request.Method, c.Request.URL.Path, provided, time.Now()) {
+		mw.log.Warn().Str(preference.CLIENT_IP, c.ClientIP()).Msg("debug_error_rejected")
+		return false
+	}
+
+	return true
+}
+
+// isDebugErrorSigned checks the signature and that it expires neither in the
+// past nor further than MaxTTL, so a leaked header is only good for a while.
+func isDebugErrorSigned(opt DebugErrorOptions, method string, path string, header string, now time.Time) bool {
+	expires, signature, found := strings.Cut(header, ".")
+	if !found {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if at := time.Unix(unix, 0); at.Before(now) || at.After(now.Add(opt.MaxTTL)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(debugErrorSignature(opt.SigningKey, method, path, expires)))
+}
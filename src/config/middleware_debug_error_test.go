@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsDebugErrorSigned(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	opt := DebugErrorOptions{SigningKey: "key", MaxTTL: 15 * time.Minute}
+
+	tests := []struct {
+		name   string
+		header string
+		method string
+		path   string
+		want   bool
+	}{
+		{
+			name:   "valid",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(time.Minute)),
+			method: "GET", path: "/users/1", want: true,
+		},
+		{
+			name:   "valid at the max ttl",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(15*time.Minute)),
+			method: "GET", path: "/users/1", want: true,
+		},
+		{
+			name:   "beyond the max ttl",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(16*time.Minute)),
+			method: "GET", path: "/users/1", want: false,
+		},
+		{
+			name:   "expired",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(-time.Second)),
+			method: "GET", path: "/users/1", want: false,
+		},
+		{
+			name:   "other method",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(time.Minute)),
+			method: "DELETE", path: "/users/1", want: false,
+		},
+		{
+			name:   "other path",
+			header: SignDebugError("key", "GET", "/users/1", now.Add(time.Minute)),
+			method: "GET", path: "/users/2", want: false,
+		},
+		{
+			name:   "other key",
+			header: SignDebugError("other", "GET", "/users/1", now.Add(time.Minute)),
+			method: "GET", path: "/users/1", want: false,
+		},
+		{
+			name:   "expiry changed after signing",
+			header: "1700000030." + debugErrorSignature("key", "GET", "/users/1", "1700000060"),
+			method: "GET", path: "/users/1", want: false,
+		},
+		{name: "no separator", header: "1700000060", method: "GET", path: "/users/1", want: false},
+		{name: "no expiry", header: ".abc", method: "GET", path: "/users/1", want: false},
+		{name: "empty", header: "", method: "GET", path: "/users/1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isDebugErrorSigned(opt, tt.method, tt.path, tt.header, now)
+			if got != tt.want {
+				t.Errorf("isDebugErrorSigned(%q, %s %s) = %v, want %v", tt.header, tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
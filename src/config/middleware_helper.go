@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"learngolang/src/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbortWithError writes the standard error envelope or problem, for components outside the rest handler.
+func (mw *middleware) AbortWithError(c *gin.Context, err error) {
+	mw.httpRespError(c, err)
+}
+
+func (mw *middleware) httpRespError(c *gin.Context, appErr error) {
+	statusCode, displayError := mw.CompileError(c, appErr)
+
+	// the access log reads the error code from here
+	_ = c.Error(appErr)
+
+	if mw.UseProblemJSON(c) {
+		c.Abort()
+		WriteProblemJSON(c, statusCode, NewProblemDetails(c, statusCode, displayError))
+		return
+	}
+
+	statusStr := http.StatusText(statusCode)
+
+	jsonErrResp := &dto.HTTPErrorResp{
+		Meta: dto.Meta{
+			Path:       c.Request.URL.Path,
+			StatusCode: statusCode,
+			Status:     statusStr,
+			Message:    fmt.Sprintf("%s %s [%d] %s", c.Request.Method, c.Request.RequestURI, statusCode, http.StatusText(statusCode)),
+			Error:      &displayError,
+			Timestamp:  time.Now().Format(time.RFC3339),
+			RequestID:  mw.getRequestID(c.Request.Context()),
+		},
+	}
+
+	c.AbortWithStatusJSON(statusCode, jsonErrResp)
+}
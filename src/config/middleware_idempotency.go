@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+)
+
+const (
+	idempotencyKeyPrefix        string = "idempotency:"
+	idempotencyLockSuffix       string = ":lock"
+	defaultIdempotencyTTL              = 24 * time.Hour
+	defaultIdempotencyLockTTL          = 30 * time.Second
+	defaultIdempotencyMaxKeyLen        = 255
+)
+
+// releaseLockScript deletes the lock only when it is still owned by the caller,
+// so an expired lock taken over by another request is never released by us.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type IdempotencyOptions struct {
+	Enabled      bool          `yaml:"enabled"`
+	TTL          time.Duration `yaml:"ttl"`
+	LockTTL      time.Duration `yaml:"lock_ttl"`
+	MaxKeyLength int           `yaml:"max_key_length"`
+}
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+	// limit caps the captured bytes, 0 captures the whole body
+	limit int
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	if w.limit > 0 {
+		b = b[:max(min(len(b), w.limit-w.body.Len()), 0)]
+	}
+
+	w.body.Write(b)
+}
+
+// truncated tells if more bytes were written than captured.
+func (w *bodyCaptureWriter) truncated() bool {
+	return w.ResponseWriter.Size() > w.body.Len()
+}
+
+func withIdempotencyDefaults(opt IdempotencyOptions) IdempotencyOptions {
+	if opt.TTL <= 0 {
+		opt.TTL = defaultIdempotencyTTL
+	}
+
+	if opt.LockTTL <= 0 {
+		opt.LockTTL = defaultIdempotencyLockTTL
+	}
+
+	if opt.MaxKeyLength <= 0 {
+		opt.MaxKeyLength = defaultIdempotencyMaxKeyLen
+	}
+
+	return opt
+}
+
+func (mw *middleware) Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opt := mw.settings.Load().idempotency
+		key := c.GetHeader(preference.IDEMPOTENCY_KEY)
+		if !opt.Enabled || mw.rdb == nil || key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		if len(key) > opt.MaxKeyLength {
+			mw.httpRespError(c, exception.NewWithCode(exception.CodeHTTPBadRequest, "idempotency_key_too_long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			mw.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPErrorOnReadBody, "idempotency_read_body"))
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := idempotencyFingerprint(c.Request, body)
+		recordKey := idempotencyKeyPrefix + idempotencyScope(c, key)
+		lockKey := recordKey + idempotencyLockSuffix
+
+		record, err := mw.getIdempotencyRecord(c, recordKey)
+		if err != nil && err != redis.Nil {
+			// fail open, a redis outage must not block the endpoint itself
+			ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_get_record")
+			c.Next()
+			return
+		}
+
+		if err == nil {
+			mw.replayIdempotencyRecord(c, record, fingerprint)
+			return
+		}
+
+		lockToken := xid.New().String()
+		acquired, err := mw.rdb.SetNX(ctx, lockKey, lockToken, opt.LockTTL).Result()
+		if err != nil {
+			ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_acquire_lock")
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			c.Header("Retry-After", "1")
+			mw.httpRespError(c, exception.NewWithCode(exception.CodeHTTPConflict, "idempotency_request_in_progress"))
+			return
+		}
+
+		defer func() {
+			if err := releaseLockScript.Run(ctx, mw.rdb, []string{lockKey}, lockToken).Err(); err != nil {
+				ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_release_lock")
+			}
+		}()
+
+		// a request holding the lock may have stored its record between the
+		// read above and our lock, it must be replayed and not run again
+		if record, err := mw.getIdempotencyRecord(c, recordKey); err == nil {
+			mw.replayIdempotencyRecord(c, record, fingerprint)
+			return
+		} else if err != redis.Nil {
+			ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_get_record")
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		// server errors are not cached so the client is able to retry them
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		data, err := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_marshal_record")
+			return
+		}
+
+		if err := mw.rdb.Set(ctx, recordKey, data, opt.TTL).Err(); err != nil {
+			ComponentLogger(ctx, LogComponentMiddleware).Warn().Err(err).Str("idempotency_key", key).Msg("idempotency_set_record")
+		}
+	}
+}
+
+func (mw *middleware) getIdempotencyRecord(c *gin.Context, recordKey string) (idempotencyRecord, error) {
+	var record idempotencyRecord
+
+	raw, err := mw.rdb.Get(c.Request.Context(), recordKey).Bytes()
+	if err != nil {
+		return record, err
+	}
+
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return record, exception.WrapWithCode(err, exception.CodeCacheUnmarshal, "idempotency_unmarshal_record")
+	}
+
+	return record, nil
+}
+
+func (mw *middleware) replayIdempotencyRecord(c *gin.Context, record idempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		mw.httpRespError(c, exception.NewWithCode(exception.CodeHTTPUnprocessableEntity, "idempotency_key_reused_with_different_request"))
+		return
+	}
+
+	c.Header(preference.IDEMPOTENT_REPLAYED, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+	c.Abort()
+}
+
+// idempotencyScope keeps the keys of a client, a method and a route apart, so
+// a key sent by someone else or to another endpoint never replays a response.
+func idempotencyScope(c *gin.Context, key string) string {
+	client := "ip:" + c.ClientIP()
+
+	switch {
+	case c.GetString(preference.CONTEXT_KEY_USER) != "":
+		client = "user:" + c.GetString(preference.CONTEXT_KEY_USER)
+	case c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0:
+		client = "cert:" + c.Request.TLS.VerifiedChains[0][0].Subject.CommonName
+	case c.GetHeader("Authorization") != "":
+		client = "auth:" + c.GetHeader("Authorization")
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{client, c.Request.Method, routeTemplate(c), key} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func idempotencyFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(req.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// fakeRedis answers the commands of the idempotency middleware from memory,
+// the client never dials.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	// locked makes every lock look taken by another request
+	locked bool
+	// err fails every command
+	err error
+}
+
+func newFakeRedis(t *testing.T, fake *fakeRedis) *redis.Client {
+	fake.values = make(map[string]string)
+
+	client := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+	client.AddHook(fake)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+func (f *fakeRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("fake redis does not dial")
+	}
+}
+
+func (f *fakeRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f *fakeRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.err != nil {
+			cmd.SetErr(f.err)
+			return f.err
+		}
+
+		args := cmd.Args()
+		key := fakeRedisString(args[1])
+
+		switch cmd := cmd.(type) {
+		case *redis.StringCmd:
+			value, ok := f.values[key]
+			if !ok {
+				cmd.SetErr(redis.Nil)
+				return redis.Nil
+			}
+
+			cmd.SetVal(value)
+		case *redis.BoolCmd:
+			_, exists := f.values[key]
+			if exists || (f.locked && strings.HasSuffix(key, idempotencyLockSuffix)) {
+				cmd.SetVal(false)
+				return nil
+			}
+
+			f.values[key] = fakeRedisString(args[2])
+			cmd.SetVal(true)
+		case *redis.StatusCmd:
+			f.values[key] = fakeRedisString(args[2])
+			cmd.SetVal("OK")
+		case *redis.Cmd:
+			// evalsha of releaseLockScript: sha, numkeys, key, token
+			lockKey, token := fakeRedisString(args[3]), fakeRedisString(args[4])
+			if f.values[lockKey] == token {
+				delete(f.values, lockKey)
+				cmd.SetVal(int64(1))
+				return nil
+			}
+
+			cmd.SetVal(int64(0))
+		default:
+			return fmt.Errorf("fake redis: unsupported command %v", args)
+		}
+
+		return nil
+	}
+}
+
+func fakeRedisString(arg any) string {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+
+	return fmt.Sprint(arg)
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		key  string
+		body string
+		// status is what the handler answers
+		status       int
+		wantStatus   int
+		wantReplayed bool
+	}
+
+	tests := []struct {
+		name      string
+		fake      *fakeRedis
+		requests  []request
+		wantCalls int
+	}{
+		{
+			name: "replays the stored response",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusCreated, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "other body with the same key",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+				{key: "k1", body: `{"name":"b"}`, status: http.StatusCreated, wantStatus: http.StatusUnprocessableEntity},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "other keys run",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+				{key: "k2", body: `{"name":"a"}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "server errors are not stored",
+			requests: []request{
+				{key: "k1", body: `{}`, status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{key: "k1", body: `{}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "request in progress",
+			fake: &fakeRedis{locked: true},
+			requests: []request{
+				{key: "k1", body: `{}`, status: http.StatusCreated, wantStatus: http.StatusConflict},
+			},
+			wantCalls: 0,
+		},
+		{
+			name: "fails open on redis errors",
+			fake: &fakeRedis{err: errors.New("connection refused")},
+			requests: []request{
+				{key: "k1", body: `{}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+				{key: "k1", body: `{}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "no key",
+			requests: []request{
+				{body: `{}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+				{body: `{}`, status: http.StatusCreated, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := tt.fake
+			if fake == nil {
+				fake = &fakeRedis{}
+			}
+
+			mw := &middleware{log: zerolog.Nop(), rdb: newFakeRedis(t, fake)}
+			mw.Reload(MiddlewareOptions{Idempotency: IdempotencyOptions{Enabled: true}})
+
+			calls := 0
+			status := 0
+			router := gin.New()
+			router.POST("/users", mw.Idempotency(), func(c *gin.Context) {
+				calls++
+				c.JSON(status, gin.H{"call": calls})
+			})
+
+			var first string
+			for i, req := range tt.requests {
+				status = req.status
+
+				r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(preference.IDEMPOTENCY_KEY, req.key)
+				}
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+
+				if w.Code != req.wantStatus {
+					t.Errorf("request %d status = %d, want %d", i, w.Code, req.wantStatus)
+				}
+
+				replayed := w.Header().Get(preference.IDEMPOTENT_REPLAYED) == "true"
+				if replayed != req.wantReplayed {
+					t.Errorf("request %d replayed = %v, want %v", i, replayed, req.wantReplayed)
+				}
+
+				if i == 0 {
+					first = w.Body.String()
+				} else if req.wantReplayed && w.Body.String() != first {
+					t.Errorf("request %d body = %s, want the stored %s", i, w.Body.String(), first)
+				}
+
+				if req.wantStatus == http.StatusConflict && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d has no Retry-After", i)
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"learngolang/src/dto"
+	exception "learngolang/src/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ErrorFormatEnvelope string = "envelope"
+	ErrorFormatProblem  string = "problem"
+
+	MIMEProblemJSON string = "application/problem+json"
+)
+
+// UseProblemJSON tells if the error of the request is written as RFC 7807,
+// either because the config says so or because the client asked for it.
+func (mw *middleware) UseProblemJSON(c *gin.Context) bool {
+	if mw.settings.Load().errorFormat == ErrorFormatProblem {
+		return true
+	}
+
+	return acceptsProblemJSON(c.Request.Header.Values("Accept"))
+}
+
+// acceptsProblemJSON looks for problem+json with a non zero quality, the
+// wildcards are ignored so clients written for the envelope keep getting it.
+func acceptsProblemJSON(accept []string) bool {
+	for _, header := range accept {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != MIMEProblemJSON {
+				continue
+			}
+
+			q, ok := params["q"]
+			if !ok {
+				return true
+			}
+
+			if quality, err := strconv.ParseFloat(q, 64); err == nil && quality > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NewProblemDetails builds the RFC 7807 body of an error compiled by errors.Compile.
+func NewProblemDetails(c *gin.Context, statusCode int, appErr exception.AppError) *dto.ProblemDetails {
+	return &dto.ProblemDetails{
+		Type:      exception.ProblemType(appErr.Code),
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    appErr.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      appErr.Code,
+		RequestID: RequestIDFromContext(c.Request.Context()),
+		Debug:     appErr.DebugError,
+		Errors:    appErr.Errors,
+	}
+}
+
+// WriteProblemJSON sets the content type first, gin keeps it when rendering json.
+func WriteProblemJSON(c *gin.Context, statusCode int, problem *dto.ProblemDetails) {
+	c.Header("Content-Type", MIMEProblemJSON+"; charset=utf-8")
+	c.JSON(statusCode, problem)
+}
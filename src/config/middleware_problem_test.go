@@ -0,0 +1,49 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func TestUseProblemJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		errorFormat string
+		accept      []string
+		want        bool
+	}{
+		{name: "envelope by default", want: false},
+		{name: "problem format", errorFormat: ErrorFormatProblem, want: true},
+		{name: "problem format ignores accept", errorFormat: ErrorFormatProblem, accept: []string{"application/json"}, want: true},
+		{name: "accept problem", accept: []string{"application/problem+json"}, want: true},
+		{name: "accept problem in a list", accept: []string{"application/json, application/problem+json;q=0.9"}, want: true},
+		{name: "accept problem in a second header", accept: []string{"application/json", "application/problem+json"}, want: true},
+		{name: "accept problem with zero quality", accept: []string{"application/problem+json;q=0"}, want: false},
+		{name: "accept problem with invalid quality", accept: []string{"application/problem+json;q=high"}, want: false},
+		{name: "wildcards keep the envelope", accept: []string{"*/*", "application/*"}, want: false},
+		{name: "envelope format honours accept", errorFormat: ErrorFormatEnvelope, accept: []string{"application/problem+json"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := &middleware{log: zerolog.Nop()}
+			mw.Reload(MiddlewareOptions{ErrorFormat: tt.errorFormat})
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/users", nil)
+			for _, accept := range tt.accept {
+				c.Request.Header.Add("Accept", accept)
+			}
+
+			if got := mw.UseProblemJSON(c); got != tt.want {
+				t.Errorf("UseProblemJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (mw *middleware) Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			ctx := c.Request.Context()
+			mw.metrics.IncPanic()
+
+			ComponentLogger(ctx, LogComponentMiddleware).Error().
+				Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), mw.getRequestID(ctx)).
+				Str(preference.METHOD, c.Request.Method).
+				Str(preference.URL, c.Request.URL.Path).
+				Str("panic", fmt.Sprint(rec)).
+				Str("stack", string(debug.Stack())).
+				Msg("panic_recovered")
+
+			// the client is gone, there is nobody left to write the response to
+			if isBrokenPipe(rec) {
+				c.Abort()
+				return
+			}
+
+			// the panic value stays in the log above, it may hold internal data
+			mw.httpRespError(c, exception.NewWithCode(exception.CodeHTTPInternalServerError, "panic_recovered"))
+		}()
+
+		c.Next()
+	}
+}
+
+func isBrokenPipe(rec any) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var sysErr *os.SyscallError
+	if errors.As(opErr, &sysErr) && (errors.Is(sysErr.Err, syscall.EPIPE) || errors.Is(sysErr.Err, syscall.ECONNRESET)) {
+		return true
+	}
+
+	msg := strings.ToLower(opErr.Error())
+
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
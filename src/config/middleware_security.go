@@ -0,0 +1,71 @@
+package config
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultFrameOptions            string = "DENY"
+	defaultContentSecurityPolicy   string = "default-src 'self'; connect-src *; font-src *; script-src-elem * 'unsafe-inline'; img-src * data:; style-src * 'unsafe-inline';"
+	defaultXSSProtection           string = "1; mode=block"
+	defaultStrictTransportSecurity string = "max-age=31536000; includeSubDomains; preload"
+	defaultReferrerPolicy          string = "strict-origin"
+	defaultContentTypeOptions      string = "nosniff"
+	defaultPermissionsPolicy       string = "geolocation=(),midi=(),sync-xhr=(),microphone=(),camera=(),magnetometer=(),gyroscope=(),fullscreen=(self),payment=()"
+)
+
+type SecurityHeadersOptions struct {
+	// Enabled is true when the config leaves it out
+	Enabled                 bool   `yaml:"enabled"`
+	FrameOptions            string `yaml:"frame_options"`
+	ContentSecurityPolicy   string `yaml:"content_security_policy"`
+	XSSProtection           string `yaml:"xss_protection"`
+	StrictTransportSecurity string `yaml:"strict_transport_security"`
+	ReferrerPolicy          string `yaml:"referrer_policy"`
+	ContentTypeOptions      string `yaml:"content_type_options"`
+	PermissionsPolicy       string `yaml:"permissions_policy"`
+}
+
+// newSecurityHeaders returns the headers to send, nil when disabled.
+func newSecurityHeaders(opt SecurityHeadersOptions) map[string]string {
+	if !opt.Enabled {
+		return nil
+	}
+
+	headers := map[string]string{
+		"X-Frame-Options":           valueOrDefault(opt.FrameOptions, defaultFrameOptions),
+		"Content-Security-Policy":   valueOrDefault(opt.ContentSecurityPolicy, defaultContentSecurityPolicy),
+		"X-XSS-Protection":          valueOrDefault(opt.XSSProtection, defaultXSSProtection),
+		"Strict-Transport-Security": valueOrDefault(opt.StrictTransportSecurity, defaultStrictTransportSecurity),
+		"Referrer-Policy":           valueOrDefault(opt.ReferrerPolicy, defaultReferrerPolicy),
+		"X-Content-Type-Options":    valueOrDefault(opt.ContentTypeOptions, defaultContentTypeOptions),
+		"Permissions-Policy":        valueOrDefault(opt.PermissionsPolicy, defaultPermissionsPolicy),
+	}
+
+	// a header configured as "-" is not sent at all
+	for name, value := range headers {
+		if value == "-" {
+			delete(headers, name)
+		}
+	}
+
+	return headers
+}
+
+func (mw *middleware) SecurityHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for name, value := range mw.settings.Load().securityHeaders {
+			c.Header(name, value)
+		}
+
+		c.Next()
+	}
+}
+
+func valueOrDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"learngolang/src/preference"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func TestIsDebugLogAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mw := &middleware{log: zerolog.Nop()}
+
+	tests := []struct {
+		name   string
+		header string
+		admin  bool
+		want   bool
+	}{
+		{name: "no header", header: "", admin: true, want: false},
+		{name: "admin", header: "true", admin: true, want: true},
+		{name: "admin with 1", header: "1", admin: true, want: true},
+		{name: "admin with false", header: "false", admin: true, want: false},
+		{name: "admin with garbage", header: "please", admin: true, want: false},
+		{name: "not an admin", header: "true", admin: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.header != "" {
+				c.Request.Header.Set(preference.HEADER_DEBUG_LOG, tt.header)
+			}
+
+			if tt.admin {
+				c.Set(preference.CONTEXT_KEY_ADMIN, true)
+			}
+
+			if got := mw.isDebugLogAllowed(c); got != tt.want {
+				t.Errorf("isDebugLogAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startRequestSpan starts the server span of the request as a child of the
+// inbound traceparent, the route is only known once gin has matched it.
+func (mw *middleware) startRequestSpan(ctx context.Context, c *gin.Context) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(c.Request.Header))
+
+	return startRootSpan(ctx, "HTTP "+c.Request.Method, trace.SpanKindServer,
+		attribute.String("http.request.method", c.Request.Method),
+		attribute.String("url.path", c.Request.URL.Path),
+		attribute.String("client.address", c.ClientIP()),
+		attribute.String("user_agent.original", c.Request.UserAgent()),
+		attribute.String("request.id", mw.getRequestID(ctx)),
+	)
+}
+
+func (mw *middleware) endRequestSpan(c *gin.Context, span trace.Span) {
+	route := routeTemplate(c)
+	status := c.Writer.Status()
+
+	span.SetName(c.Request.Method + " " + route)
+	span.SetAttributes(
+		attribute.String("http.route", route),
+		attribute.Int("http.response.status_code", status),
+	)
+
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	if err := c.Errors.Last(); err != nil {
+		span.RecordError(err.Err)
+	}
+
+	span.End()
+}
@@ -2,12 +2,18 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type QueriesOptions struct {
@@ -15,54 +21,63 @@ type QueriesOptions struct {
 }
 
 type QueryLoader struct {
-	queries  map[string]string
-	filePath string
+	queries map[string]string
 }
 
 func InitQueryLoader(log zerolog.Logger, opt QueriesOptions) (*QueryLoader, error) {
-	queryPath := fmt.Sprintf("%s/user_queries.sql", opt.Path)
-	ql := &QueryLoader{
-		queries:  make(map[string]string),
-		filePath: queryPath,
-	}
-
-	if err := ql.load(log); err != nil {
+	queries, err := ReadQueries(opt)
+	if err != nil {
 		return nil, err
 	}
 
-	return ql, nil
+	log.Info().Int("count", len(queries)).Msg("Queries loaded successfully")
+
+	return &QueryLoader{queries: queries}, nil
 }
 
-func (ql *QueryLoader) load(log zerolog.Logger) error {
-	data, err := os.ReadFile(ql.filePath)
+// ReadQueries loads the named queries of every .sql file of the queries
+// directory, it is used by the config validation too.
+func ReadQueries(opt QueriesOptions) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(opt.Path, "*.sql"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	content := string(data)
-	sections := strings.Split(content, "-- name:")
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql file in %s", opt.Path)
+	}
 
-	for _, section := range sections {
-		if strings.TrimSpace(section) == "" {
-			continue
+	queries := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
 		}
 
-		lines := strings.Split(section, "\n")
-		if len(lines) < 2 {
-			continue
-		}
+		for _, section := range strings.Split(string(data), "-- name:") {
+			if strings.TrimSpace(section) == "" {
+				continue
+			}
 
-		name := strings.TrimSpace(lines[0])
-		query := strings.Join(lines[1:], "\n")
-		query = strings.TrimSpace(query)
-		query = strings.TrimSuffix(query, ";")
+			lines := strings.Split(section, "\n")
+			if len(lines) < 2 {
+				continue
+			}
 
-		ql.queries[name] = query
-	}
+			name := strings.TrimSpace(lines[0])
+			query := strings.Join(lines[1:], "\n")
+			query = strings.TrimSpace(query)
+			query = strings.TrimSuffix(query, ";")
 
-	log.Info().Int("count", len(ql.queries)).Msg("Queries loaded successfully")
+			if _, ok := queries[name]; ok {
+				return nil, fmt.Errorf("query %s in %s is defined twice", name, file)
+			}
 
-	return nil
+			queries[name] = query
+		}
+	}
+
+	return queries, nil
 }
 
 func (ql *QueryLoader) Get(name string) (string, bool) {
@@ -71,6 +86,22 @@ func (ql *QueryLoader) Get(name string) (string, bool) {
 	return query, ok
 }
 
+// StartSpan starts the client span of a query, named after the query in the sql file.
+func (ql *QueryLoader) StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "sql "+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.operation.name", name),
+	))
+}
+
+// EndQuerySpan ends the span of StartSpan, sql.ErrNoRows is an empty result and not an error.
+func EndQuerySpan(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	EndSpan(span, err)
+}
+
 func (ql *QueryLoader) ExecuteTemplate(name string, data any) (string, []any, error) {
 	queryTemplate, ok := ql.Get(name)
 	if !ok {
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"learngolang/src/preference"
@@ -15,7 +16,8 @@ type RedisOptions struct {
 	Enabled         bool          `yaml:"enabled"`
 	Network         string        `yaml:"network"`
 	Address         string        `yaml:"address"`
-	Password        string        `yaml:"password"`
+	Password        string        `yaml:"password" secret:"true"`
+	PasswordFile    string        `yaml:"password_file"`
 	CacheTTL        time.Duration `yaml:"cache_ttl"`
 	MaxRetries      int           `yaml:"max_retries"`
 	MinRetryBackoff time.Duration `yaml:"min_retry_backoff"`
@@ -30,6 +32,16 @@ type RedisOptions struct {
 	PoolTimeout     time.Duration `yaml:"pool_timeout"`
 }
 
+// redisPassword is read on every new connection, every client shares the
+// options so they share the password too
+var redisPassword atomic.Pointer[string]
+
+// SetRedisPassword authenticates the next connections with password, the open
+// ones keep their session.
+func SetRedisPassword(password string) {
+	redisPassword.Store(&password)
+}
+
 func InitRedis(log zerolog.Logger, opt RedisOptions, redisType string) *redis.Client {
 	var redisClient *redis.Client
 	var DB int
@@ -47,10 +59,14 @@ func InitRedis(log zerolog.Logger, opt RedisOptions, redisType string) *redis.Cl
 		DB = 13
 	}
 
+	SetRedisPassword(opt.Password)
+
 	redisClient = redis.NewClient(&redis.Options{
-		Network:         opt.Network,
-		Addr:            opt.Address,
-		Password:        opt.Password,
+		Network: opt.Network,
+		Addr:    opt.Address,
+		CredentialsProvider: func() (string, string) {
+			return "", *redisPassword.Load()
+		},
 		DB:              DB,
 		MaxRetries:      opt.MaxRetries,
 		MinRetryBackoff: opt.MinRetryBackoff,
@@ -65,6 +81,9 @@ func InitRedis(log zerolog.Logger, opt RedisOptions, redisType string) *redis.Cl
 		PoolTimeout:     opt.PoolTimeout,
 	})
 
+	redisClient.AddHook(&redisLogHook{redisType: redisType})
+	redisClient.AddHook(&redisTraceHook{redisType: redisType})
+
 	ping, err := redisClient.Ping(context.Background()).Result()
 	if err != nil {
 		log.Panic().Err(err).Msg(fmt.Sprintf("REDIS %s status: %s", redisType, ping))
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisLogHook logs failed commands through the context logger, so they carry
+// the request ID of the request or job that issued them.
+type redisLogHook struct {
+	redisType string
+}
+
+func (h *redisLogHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *redisLogHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if err != nil && err != redis.Nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("redis", h.redisType).Str("command", cmd.Name()).Msg("redis_command_failed")
+		}
+
+		return err
+	}
+}
+
+func (h *redisLogHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		if err != nil && err != redis.Nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("redis", h.redisType).Int("commands", len(cmds)).Msg("redis_pipeline_failed")
+		}
+
+		return err
+	}
+}
+
+// redisTraceHook records a client span per command or pipeline, redis.Nil is a
+// cache miss and not an error.
+type redisTraceHook struct {
+	redisType string
+}
+
+func (h *redisTraceHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *redisTraceHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracer.Start(ctx, "redis "+cmd.Name(), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("db.system.name", "redis"),
+			attribute.String("db.operation.name", cmd.Name()),
+			attribute.String("db.namespace", h.redisType),
+		))
+
+		err := next(ctx, cmd)
+		EndSpan(span, redisSpanError(err))
+
+		return err
+	}
+}
+
+func (h *redisTraceHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracer.Start(ctx, "redis pipeline", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("db.system.name", "redis"),
+			attribute.String("db.operation.name", "pipeline"),
+			attribute.String("db.namespace", h.redisType),
+			attribute.Int("db.operation.batch.size", len(cmds)),
+		))
+
+		err := next(ctx, cmds)
+		EndSpan(span, redisSpanError(err))
+
+		return err
+	}
+}
+
+func redisSpanError(err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+
+	return err
+}
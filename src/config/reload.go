@@ -0,0 +1,49 @@
+package config
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+var onceReloader = &sync.Once{}
+
+// ReloadFunc re-reads, validates and applies the config, it returns the keys
+// that changed. Nothing is applied when it returns an error.
+type ReloadFunc func(ctx context.Context) ([]string, error)
+
+// Reloader serializes config reloads triggered by SIGHUP or the admin server.
+type Reloader struct {
+	log    zerolog.Logger
+	mu     sync.Mutex
+	reload ReloadFunc
+}
+
+func InitReloader(log zerolog.Logger, reload ReloadFunc) *Reloader {
+	var r *Reloader
+
+	onceReloader.Do(func() {
+		r = &Reloader{
+			log:    log,
+			reload: reload,
+		}
+	})
+
+	return r
+}
+
+func (r *Reloader) Reload(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed, err := r.reload(ctx)
+	if err != nil {
+		r.log.Error().Err(err).Msg("Config reload rejected, keeping the current config")
+		return nil, err
+	}
+
+	r.log.Info().Strs("changed", changed).Msg("Config reloaded")
+
+	return changed, nil
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"learngolang/src/preference"
+
+	"github.com/rs/xid"
+)
+
+var (
+	requestIDRegex   = regexp.MustCompile(`^[A-Za-z0-9\-_.:]{1,128}$`)
+	traceParentRegex = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+)
+
+// TraceParent is the W3C trace context carried in the `traceparent` header,
+// see https://www.w3.org/TR/trace-context/#traceparent-header.
+type TraceParent struct {
+	Version  string
+	TraceID  string
+	ParentID string
+	Flags    string
+}
+
+func (tp TraceParent) String() string {
+	return fmt.Sprintf("%s-%s-%s-%s", tp.Version, tp.TraceID, tp.ParentID, tp.Flags)
+}
+
+// Child returns the trace context for a new span of the same trace.
+func (tp TraceParent) Child() TraceParent {
+	tp.Version = "00"
+	tp.ParentID = randomHex(8)
+
+	return tp
+}
+
+func NewTraceParent() TraceParent {
+	return TraceParent{
+		Version:  "00",
+		TraceID:  randomHex(16),
+		ParentID: randomHex(8),
+		Flags:    "01",
+	}
+}
+
+func ParseTraceParent(value string) (TraceParent, bool) {
+	match := traceParentRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return TraceParent{}, false
+	}
+
+	tp := TraceParent{
+		Version:  match[1],
+		TraceID:  match[2],
+		ParentID: match[3],
+		Flags:    match[4],
+	}
+
+	if tp.Version == "ff" || tp.TraceID == strings.Repeat("0", 32) || tp.ParentID == strings.Repeat("0", 16) {
+		return TraceParent{}, false
+	}
+
+	return tp, true
+}
+
+func IsValidRequestID(reqID string) bool {
+	return requestIDRegex.MatchString(reqID)
+}
+
+// NewRequestContext resolves the request ID and trace context of an inbound
+// request. A valid X-Request-ID wins, then the trace ID of a valid traceparent,
+// otherwise a fresh ID is generated.
+func NewRequestContext(ctx context.Context, header http.Header) context.Context {
+	tp, ok := ParseTraceParent(header.Get(preference.HEADER_TRACE_PARENT))
+	if ok {
+		tp = tp.Child()
+	} else {
+		tp = NewTraceParent()
+	}
+
+	reqID := header.Get(preference.HEADER_REQUEST_ID)
+	if !IsValidRequestID(reqID) {
+		reqID = tp.TraceID
+		if !ok {
+			reqID = xid.New().String()
+		}
+	}
+
+	ctx = context.WithValue(ctx, preference.CONTEXT_KEY_REQUEST_ID, reqID)
+	ctx = context.WithValue(ctx, preference.CONTEXT_KEY_TRACE_PARENT, tp)
+
+	return ctx
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if reqID, ok := ctx.Value(preference.CONTEXT_KEY_REQUEST_ID).(string); ok {
+		return reqID
+	}
+
+	return ""
+}
+
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(preference.CONTEXT_KEY_TRACE_PARENT).(TraceParent)
+
+	return tp, ok
+}
+
+// TagQuery appends the request ID and trace context of ctx to query as a
+// sqlcommenter comment, e.g. /*request_id='x',traceparent='00-...'*/, so the
+// statement can be found in the database logs and pg_stat_activity. Only
+// values that passed validation are written, they cannot close the comment.
+func TagQuery(ctx context.Context, query string) string {
+	tags := make([]string, 0, 2)
+
+	if reqID := RequestIDFromContext(ctx); IsValidRequestID(reqID) {
+		tags = append(tags, fmt.Sprintf("request_id='%s'", reqID))
+	}
+
+	if tp, ok := TraceParentFromContext(ctx); ok {
+		if _, valid := ParseTraceParent(tp.String()); valid {
+			tags = append(tags, fmt.Sprintf("traceparent='%s'", tp))
+		}
+	}
+
+	if len(tags) == 0 {
+		return query
+	}
+
+	// on its own line, a trailing -- comment of the query would swallow it
+	return query + "\n/*" + strings.Join(tags, ",") + "*/"
+}
+
+// PropagationTransport forwards the request ID and trace context of the
+// request context to outgoing HTTP calls, e.g.
+// &http.Client{Transport: &PropagationTransport{}}.
+type PropagationTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *PropagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	reqID := RequestIDFromContext(ctx)
+	tp, ok := TraceParentFromContext(ctx)
+
+	if reqID == "" && !ok {
+		return base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the caller's request
+	req = req.Clone(ctx)
+	if reqID != "" {
+		req.Header.Set(preference.HEADER_REQUEST_ID, reqID)
+	}
+
+	if ok {
+		req.Header.Set(preference.HEADER_TRACE_PARENT, tp.Child().String())
+	}
+
+	return base.RoundTrip(req)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
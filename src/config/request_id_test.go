@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"learngolang/src/preference"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPropagationTransport(t *testing.T) {
+	inbound := http.Header{}
+	inbound.Set(preference.HEADER_REQUEST_ID, "req-1")
+	inbound.Set(preference.HEADER_TRACE_PARENT, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	requestCtx := NewRequestContext(context.Background(), inbound)
+	tp, _ := TraceParentFromContext(requestCtx)
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		header        http.Header
+		wantRequestID string
+		wantTrace     bool
+	}{
+		{name: "request context", ctx: requestCtx, wantRequestID: "req-1", wantTrace: true},
+		{
+			name:          "overrides a stale header",
+			ctx:           requestCtx,
+			header:        http.Header{preference.HEADER_REQUEST_ID: []string{"stale"}},
+			wantRequestID: "req-1",
+			wantTrace:     true,
+		},
+		{name: "no request context", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent *http.Request
+			transport := &PropagationTransport{Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = req
+				return httptest.NewRecorder().Result(), nil
+			})}
+
+			req := httptest.NewRequest(http.MethodGet, "http://downstream/users", nil).WithContext(tt.ctx)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+
+			original := req.Header.Clone()
+
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sent.Header.Get(preference.HEADER_REQUEST_ID); got != tt.wantRequestID {
+				t.Errorf("X-Request-ID = %q, want %q", got, tt.wantRequestID)
+			}
+
+			got, ok := ParseTraceParent(sent.Header.Get(preference.HEADER_TRACE_PARENT))
+			if ok != tt.wantTrace {
+				t.Fatalf("traceparent %q valid = %v, want %v", sent.Header.Get(preference.HEADER_TRACE_PARENT), ok, tt.wantTrace)
+			}
+
+			if ok && (got.TraceID != tp.TraceID || got.ParentID == tp.ParentID) {
+				t.Errorf("traceparent = %s, want a child of %s", got, tp)
+			}
+
+			if len(req.Header) != len(original) || req.Header.Get(preference.HEADER_REQUEST_ID) != original.Get(preference.HEADER_REQUEST_ID) {
+				t.Errorf("RoundTrip modified the caller's request headers: %v", req.Header)
+			}
+		})
+	}
+}
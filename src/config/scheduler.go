@@ -4,16 +4,25 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Scheduler struct {
-	log  zerolog.Logger
-	cron *cron.Cron
-	jobs []Job
-	mu   sync.RWMutex
+	log     zerolog.Logger
+	cron    *cron.Cron
+	jobs    []Job
+	entries map[string]cron.EntryID
+	mu      sync.RWMutex
+	metrics *Metrics
+	paused  bool
 }
 
 type Job interface {
@@ -39,12 +48,14 @@ type UserGeneratorJobOptions struct {
 	MaxAge    int    `yaml:"max_age"`
 }
 
-func InitScheduler(log zerolog.Logger, opt SchedulerOptions) *Scheduler {
+func InitScheduler(log zerolog.Logger, opt SchedulerOptions, metrics *Metrics) *Scheduler {
 	if opt.Enabled {
 		return &Scheduler{
-			log:  log,
-			cron: cron.New(cron.WithSeconds()),
-			jobs: make([]Job, 0),
+			log:     WithLogComponent(log, LogComponentScheduler),
+			cron:    cron.New(cron.WithSeconds()),
+			jobs:    make([]Job, 0),
+			entries: make(map[string]cron.EntryID),
+			metrics: metrics,
 		}
 	}
 
@@ -55,27 +66,81 @@ func (s *Scheduler) AddJob(job Job) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.cron.AddFunc(job.Schedule(), func() {
-		s.log.Info().Str("job", job.Name()).Msg("Job started")
-
-		ctx := context.Background()
-		if err := job.Run(ctx); err != nil {
-			s.log.Error().Err(err).Str("job", job.Name()).Msg("Job execution failed")
-			return
-		}
-
-		s.log.Info().Str("job", job.Name()).Msg("Job completed successfully")
+	id, err := s.cron.AddFunc(job.Schedule(), func() {
+		s.runJob(job)
 	})
 	if err != nil {
 		return err
 	}
 
 	s.jobs = append(s.jobs, job)
+	s.entries[job.Name()] = id
 	s.log.Info().Str("job", job.Name()).Str("schedule", job.Schedule()).Msg("Job registered")
 
 	return nil
 }
 
+// Reschedule replaces the schedule of a registered job, it is called on config
+// reload. The new spec is parsed before the old entry is removed.
+func (s *Scheduler) Reschedule(name string, spec string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.Name() != name {
+			continue
+		}
+
+		id, err := s.cron.AddFunc(spec, func() {
+			s.runJob(job)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.cron.Remove(s.entries[name])
+		s.entries[name] = id
+		s.log.Info().Str("job", name).Str("schedule", spec).Msg("Job rescheduled")
+
+		return nil
+	}
+
+	return exception.NewWithCode(exception.CodeHTTPNotFound, fmt.Sprintf("job %s is not registered", name))
+}
+
+func (s *Scheduler) runJob(job Job) {
+	// every run gets its own request ID so its logs can be correlated like an HTTP request
+	ctx := NewRequestContext(context.Background(), nil)
+	ctx, span := startRootSpan(ctx, "job "+job.Name(), trace.SpanKindInternal,
+		attribute.String("job.name", job.Name()),
+		attribute.String("request.id", RequestIDFromContext(ctx)),
+	)
+
+	logCtx := s.log.With().
+		Str(string(preference.CONTEXT_KEY_LOG_REQUEST_ID), RequestIDFromContext(ctx)).
+		Str(preference.JOB, job.Name())
+	if tp, ok := TraceParentFromContext(ctx); ok {
+		logCtx = logCtx.Str(preference.TRACE_ID, tp.TraceID)
+	}
+
+	log := logCtx.Logger()
+	ctx = log.WithContext(ctx)
+
+	log.Info().Msg("Job started")
+
+	start := time.Now()
+	err := job.Run(ctx)
+	s.metrics.ObserveJob(job.Name(), err, time.Since(start))
+	EndSpan(span, err)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Job execution failed")
+		return
+	}
+
+	log.Info().Msg("Job completed successfully")
+}
+
 func (s *Scheduler) Start() {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -84,13 +149,21 @@ func (s *Scheduler) Start() {
 	s.log.Debug().Msg("Scheduler started, jobs registered: " + fmt.Sprint(len(s.jobs)))
 }
 
-func (s *Scheduler) Stop() {
+// Stop prevents new runs and waits for the running jobs until ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	ctx := s.cron.Stop()
-	<-ctx.Done()
-	s.log.Info().Msg("Scheduler stopped")
+	jobsCtx := s.cron.Stop()
+
+	select {
+	case <-jobsCtx.Done():
+		s.log.Info().Msg("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		s.log.Warn().Msg("Scheduler stopped before running jobs completed")
+		return ctx.Err()
+	}
 }
 
 func (s *Scheduler) ListJobs() []string {
@@ -104,3 +177,52 @@ func (s *Scheduler) ListJobs() []string {
 
 	return jobNames
 }
+
+// Trigger runs the job immediately, outside of its schedule.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.jobs {
+		if job.Name() == name {
+			go s.runJob(job)
+			return nil
+		}
+	}
+
+	return exception.NewWithCode(exception.CodeHTTPNotFound, fmt.Sprintf("job %s is not registered", name))
+}
+
+// Pause stops scheduling new runs, running jobs are left to complete.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		return
+	}
+
+	s.cron.Stop()
+	s.paused = true
+	s.log.Info().Msg("Scheduler paused")
+}
+
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return
+	}
+
+	s.cron.Start()
+	s.paused = false
+	s.log.Info().Msg("Scheduler resumed")
+}
+
+func (s *Scheduler) IsPaused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.paused
+}
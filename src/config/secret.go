@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	SecretProviderFile      string = "file"
+	SecretProviderEncrypted string = "encrypted"
+
+	secretKeySize = 32
+)
+
+type SecretsOptions struct {
+	// Dir holds one file per secret, referenced as file://<name>
+	Dir string `yaml:"dir"`
+	// EncryptedFile is an AES-256-GCM encrypted yaml map, referenced as encrypted://<name>
+	EncryptedFile string `yaml:"encrypted_file"`
+	// KeyFile holds the 32 bytes key of EncryptedFile, raw, hex or base64
+	KeyFile string `yaml:"key_file"`
+}
+
+// SecretProvider resolves secret references of the config, e.g. a Vault client.
+// Secrets are resolved on every config load, so a rotated database, redis,
+// auth or admin secret is picked up on reload, the others need a restart. The
+// auth key files are read again on every reload, even when rotated in place.
+type SecretProvider interface {
+	Scheme() string
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// InitSecretProviders returns the providers enabled in the options.
+func InitSecretProviders(opt SecretsOptions) ([]SecretProvider, error) {
+	providers := make([]SecretProvider, 0)
+
+	if opt.Dir != "" {
+		providers = append(providers, NewFileSecretProvider(opt.Dir))
+	}
+
+	if opt.EncryptedFile != "" {
+		provider, err := NewEncryptedSecretProvider(opt.EncryptedFile, opt.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+type fileSecretProvider struct {
+	dir string
+}
+
+func NewFileSecretProvider(dir string) SecretProvider {
+	return &fileSecretProvider{dir: dir}
+}
+
+func (p *fileSecretProvider) Scheme() string {
+	return SecretProviderFile
+}
+
+func (p *fileSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	if name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	return ReadSecretFile(filepath.Join(p.dir, name))
+}
+
+type encryptedSecretProvider struct {
+	secrets map[string]string
+}
+
+// NewEncryptedSecretProvider decrypts the whole file once, it is re-created on
+// every config load.
+func NewEncryptedSecretProvider(path string, keyFile string) (SecretProvider, error) {
+	key, err := ReadSecretKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := DecryptSecrets(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", path, err)
+	}
+
+	secrets := make(map[string]string)
+	if err := yaml.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return &encryptedSecretProvider{secrets: secrets}, nil
+}
+
+func (p *encryptedSecretProvider) Scheme() string {
+	return SecretProviderEncrypted
+}
+
+func (p *encryptedSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	secret, ok := p.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+
+	return secret, nil
+}
+
+// ReadSecretFile reads a mounted secret, the trailing newline most tools add is dropped.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func ReadSecretKey(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("secrets key_file is required to decrypt the secrets file")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == secretKeySize {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == secretKeySize {
+		return key, nil
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == secretKeySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("secrets key in %s must be %d bytes, raw, hex or base64", path, secretKeySize)
+}
+
+// EncryptSecrets seals data with AES-256-GCM, the random nonce is prepended.
+func EncryptSecrets(key []byte, data []byte) ([]byte, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func DecryptSecrets(key []byte, data []byte) ([]byte, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
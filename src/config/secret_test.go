@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecryptSecrets(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, secretKeySize)
+	plain := []byte("postgres_password: secret\n")
+
+	encrypted, err := EncryptSecrets(key, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(encrypted, plain) {
+		t.Fatal("EncryptSecrets kept the plain text")
+	}
+
+	again, err := EncryptSecrets(key, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(encrypted, again) {
+		t.Error("EncryptSecrets reused the nonce")
+	}
+
+	tampered := bytes.Clone(encrypted)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		key     []byte
+		data    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "round trip", key: key, data: encrypted, want: plain},
+		{name: "wrong key", key: bytes.Repeat([]byte{8}, secretKeySize), data: encrypted, wantErr: true},
+		{name: "tampered", key: key, data: tampered, wantErr: true},
+		{name: "too short", key: key, data: encrypted[:4], wantErr: true},
+		{name: "invalid key size", key: key[:10], data: encrypted, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptSecrets(tt.key, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("DecryptSecrets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSecretKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, secretKeySize)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr bool
+	}{
+		{name: "raw", content: key},
+		{name: "hex", content: []byte(hex.EncodeToString(key) + "\n")},
+		{name: "base64", content: []byte(base64.StdEncoding.EncodeToString(key) + "\n")},
+		{name: "too short", content: []byte(hex.EncodeToString(key[:20])), wantErr: true},
+		{name: "garbage", content: []byte("not a key"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadSecretKey(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadSecretKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !bytes.Equal(got, key) {
+				t.Errorf("ReadSecretKey() = %x, want %x", got, key)
+			}
+		})
+	}
+
+	for _, path := range []string{"", filepath.Join(dir, "missing")} {
+		if _, err := ReadSecretKey(path); err == nil {
+			t.Errorf("ReadSecretKey(%q) error = nil, want an error", path)
+		}
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "secrets")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		filepath.Join(dir, "db_password"):    "secret\n",
+		filepath.Join(dir, "redis_password"): "line1\nline2\r\n",
+		filepath.Join(root, "outside"):       "leaked",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := NewFileSecretProvider(dir)
+	if provider.Scheme() != SecretProviderFile {
+		t.Errorf("Scheme() = %q, want %q", provider.Scheme(), SecretProviderFile)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		want    string
+		wantErr bool
+	}{
+		{name: "trailing newline dropped", secret: "db_password", want: "secret"},
+		{name: "inner newlines kept", secret: "redis_password", want: "line1\nline2"},
+		{name: "missing", secret: "nope", wantErr: true},
+		{name: "parent directory", secret: "../outside", wantErr: true},
+		{name: "nested path", secret: "sub/db_password", wantErr: true},
+		{name: "absolute path", secret: filepath.Join(root, "outside"), wantErr: true},
+		{name: "dot dot", secret: "..", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.GetSecret(context.Background(), tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSecret(%q) error = %v, wantErr %v", tt.secret, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("GetSecret(%q) = %q, want %q", tt.secret, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptedSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, secretKeySize)
+	keyFile := filepath.Join(dir, "key")
+	secretsFile := filepath.Join(dir, "secrets.enc")
+
+	encrypted, err := EncryptSecrets(key, []byte("db_password: secret\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(secretsFile, encrypted, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewEncryptedSecretProvider(secretsFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := provider.GetSecret(context.Background(), "db_password"); err != nil || got != "secret" {
+		t.Errorf("GetSecret(db_password) = %q, %v, want secret", got, err)
+	}
+
+	if _, err := provider.GetSecret(context.Background(), "missing"); err == nil {
+		t.Error("GetSecret(missing) error = nil, want an error")
+	}
+
+	if _, err := NewEncryptedSecretProvider(secretsFile, ""); err == nil {
+		t.Error("NewEncryptedSecretProvider() without key file error = nil, want an error")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
 	"github.com/rs/zerolog"
 )
 
@@ -16,9 +17,24 @@ type ServerOptions struct {
 	Port            int           `yaml:"port"`
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	ReadTimeout     time.Duration `yaml:"read_timeout"`
-	IdleTimeout     time.Duration `json:"idle_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 	Mode            string        `yaml:"mode"`
+	TLS             TLSOptions    `yaml:"tls"`
+	// H2C serves HTTP/2 without TLS, meant for internal traffic only
+	H2C   bool         `yaml:"h2c"`
+	HTTP3 HTTP3Options `yaml:"http3"`
+	// UpgradeTimeout is how long a SIGUSR2 upgrade waits for the new process to serve
+	UpgradeTimeout time.Duration `yaml:"upgrade_timeout"`
+	// TrustedProxies are the addresses or CIDRs whose forwarded headers give
+	// the client IP, none are trusted when empty
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type HTTP3Options struct {
+	Enabled bool `yaml:"enabled"`
+	// Port is the UDP port, it defaults to the TCP port of the server
+	Port int `yaml:"port"`
 }
 
 func InitHttpServer(logger zerolog.Logger, opt ServerOptions, gin *gin.Engine) *http.Server {
@@ -27,14 +43,61 @@ func InitHttpServer(logger zerolog.Logger, opt ServerOptions, gin *gin.Engine) *
 	onceServer.Do(func() {
 		serverPort := fmt.Sprintf(":%d", opt.Port)
 
+		tlsConfig, err := InitTLSConfig(logger, opt.TLS)
+		if err != nil {
+			logger.Panic().Err(err).Msg("Failed to configure TLS")
+		}
+
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(tlsConfig != nil)
+		protocols.SetUnencryptedHTTP2(opt.H2C)
+
 		server = &http.Server{
 			Addr:         serverPort,
 			WriteTimeout: opt.WriteTimeout,
 			ReadTimeout:  opt.ReadTimeout,
 			IdleTimeout:  opt.IdleTimeout,
 			Handler:      gin,
+			TLSConfig:    tlsConfig,
+			Protocols:    protocols,
 		}
 	})
 
 	return server
 }
+
+// InitHttp3Server serves the same handler over QUIC with the TLS config of the
+// TCP server, the TCP responses advertise it through the Alt-Svc header.
+func InitHttp3Server(logger zerolog.Logger, opt ServerOptions, httpServer *http.Server) *http3.Server {
+	if !opt.HTTP3.Enabled {
+		return nil
+	}
+
+	if httpServer.TLSConfig == nil {
+		logger.Panic().Msg("HTTP/3 requires server.tls to be enabled")
+	}
+
+	port := opt.HTTP3.Port
+	if port == 0 {
+		port = opt.Port
+	}
+
+	h3Server := &http3.Server{
+		Addr:        fmt.Sprintf(":%d", port),
+		Port:        port,
+		Handler:     httpServer.Handler,
+		TLSConfig:   httpServer.TLSConfig,
+		IdleTimeout: opt.IdleTimeout,
+	}
+
+	handler := httpServer.Handler
+	httpServer.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = h3Server.SetQUICHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+
+	logger.Debug().Msg(fmt.Sprintf("HTTP/3 server configured on udp %s", h3Server.Addr))
+
+	return h3Server
+}
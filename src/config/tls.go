@@ -0,0 +1,275 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultTLSReloadInterval = 30 * time.Second
+
+var (
+	tlsVersions = map[string]uint16{
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+
+	tlsClientAuthTypes = map[string]tls.ClientAuthType{
+		"":                   tls.NoClientCert,
+		"none":               tls.NoClientCert,
+		"request":            tls.RequestClientCert,
+		"require":            tls.RequireAnyClientCert,
+		"verify_if_given":    tls.VerifyClientCertIfGiven,
+		"require_and_verify": tls.RequireAndVerifyClientCert,
+	}
+)
+
+type TLSOptions struct {
+	Enabled      bool     `yaml:"enabled"`
+	CertFile     string   `yaml:"cert_file"`
+	KeyFile      string   `yaml:"key_file"`
+	MinVersion   string   `yaml:"min_version"`
+	CipherSuites []string `yaml:"cipher_suites"`
+	// ClientCAFile enables mutual TLS, client certificates are verified against it
+	ClientCAFile string `yaml:"client_ca_file"`
+	ClientAuth   string `yaml:"client_auth"`
+	// ReloadInterval is how often the files are checked for changes during handshakes
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// ClientIdentity is the verified client certificate of a mutual TLS connection.
+type ClientIdentity struct {
+	CommonName   string
+	Organization []string
+	SerialNumber string
+	DNSNames     []string
+	Fingerprint  string
+}
+
+// certReloader serves the certificate and client CA pool from disk and reloads
+// them lazily on handshake once the files have changed, so no restart is needed.
+type certReloader struct {
+	log       zerolog.Logger
+	opt       TLSOptions
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+func InitTLSConfig(log zerolog.Logger, opt TLSOptions) (*tls.Config, error) {
+	if !opt.Enabled {
+		return nil, nil
+	}
+
+	if opt.ReloadInterval <= 0 {
+		opt.ReloadInterval = defaultTLSReloadInterval
+	}
+
+	minVersion, ok := tlsVersions[opt.MinVersion]
+	if opt.MinVersion == "" {
+		minVersion, ok = tls.VersionTLS12, true
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported tls min_version %q", opt.MinVersion)
+	}
+
+	cipherSuites, err := parseCipherSuites(opt.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth, ok := tlsClientAuthTypes[opt.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tls client_auth %q", opt.ClientAuth)
+	}
+
+	if opt.ClientCAFile != "" && clientAuth == tls.NoClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	reloader := &certReloader{
+		log:      log,
+		opt:      opt,
+		modTimes: make(map[string]time.Time),
+	}
+
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	baseConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	tlsConfig := baseConfig.Clone()
+	tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		reloader.maybeReload()
+
+		cfg := baseConfig.Clone()
+		cfg.Certificates = []tls.Certificate{*reloader.certificate()}
+		cfg.ClientCAs = reloader.clientCAPool()
+
+		return cfg, nil
+	}
+
+	log.Debug().
+		Str("min_version", tls.VersionName(minVersion)).
+		Str("client_auth", clientAuth.String()).
+		Msg("TLS configured")
+
+	return tlsConfig, nil
+}
+
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+func (r *certReloader) clientCAPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.clientCAs
+}
+
+func (r *certReloader) maybeReload() {
+	r.mu.RLock()
+	due := time.Since(r.checkedAt) >= r.opt.ReloadInterval
+	r.mu.RUnlock()
+
+	if !due || !r.changed() {
+		return
+	}
+
+	// a broken file on disk keeps the previous certificate in use
+	if err := r.reload(); err != nil {
+		r.log.Error().Err(err).Msg("TLS certificate reload failed, keeping the previous one")
+		return
+	}
+
+	r.log.Info().Str("cert_file", r.opt.CertFile).Msg("TLS certificate reloaded")
+}
+
+func (r *certReloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkedAt = time.Now()
+
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.opt.CertFile, r.opt.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load tls key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.opt.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opt.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read tls client ca: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in tls client ca %s", r.opt.ClientCAFile)
+		}
+	}
+
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.files() {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.opt.CertFile, r.opt.KeyFile}
+	if r.opt.ClientCAFile != "" {
+		files = append(files, r.opt.ClientCAFile)
+	}
+
+	return files
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := available[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure tls cipher suite %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ClientIdentityFromTLS returns the identity of the verified client certificate,
+// nil when the connection is not mutual TLS or the certificate was not verified.
+func ClientIdentityFromTLS(state *tls.ConnectionState) *ClientIdentity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return &ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		SerialNumber: cert.SerialNumber.String(),
+		DNSNames:     cert.DNSNames,
+		Fingerprint:  hex.EncodeToString(fingerprint[:]),
+	}
+}
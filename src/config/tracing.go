@@ -0,0 +1,231 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"learngolang/src/preference"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracingExporterOTLP   string = "otlp"
+	TracingExporterStdout string = "stdout"
+	TracingExporterFile   string = "file"
+
+	tracerName                string  = "learngolang"
+	defaultTracingServiceName string  = "learngolang"
+	defaultTracingSampleRatio float64 = 1
+)
+
+var (
+	onceTracing = &sync.Once{}
+
+	// tracer is bound to the global provider, it does nothing until InitTracing sets one
+	tracer = otel.Tracer(tracerName)
+
+	spanExporters   = map[string]SpanExporterFactory{}
+	spanExportersMu sync.RWMutex
+)
+
+// SpanExporterFactory builds the exporter selected by TracingOptions.Exporter.
+type SpanExporterFactory func(ctx context.Context, opt TracingOptions) (sdktrace.SpanExporter, error)
+
+type TracingOptions struct {
+	Enabled     bool   `yaml:"enabled"`
+	ServiceName string `yaml:"service_name"`
+	// Exporter is otlp, stdout, file or any name added with RegisterSpanExporter
+	Exporter string `yaml:"exporter"`
+	// SampleRatio applies to the traces started here, a sampled caller is always followed
+	SampleRatio float64     `yaml:"sample_ratio"`
+	OTLP        OTLPOptions `yaml:"otlp"`
+	// Path is the file of the file exporter, one json span per line
+	Path string `yaml:"path"`
+}
+
+type OTLPOptions struct {
+	// Endpoint is the host and port of the collector, e.g. localhost:4318
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+	// Headers are sent with every export, e.g. Authorization=Bearer xxx
+	Headers []string      `yaml:"headers" secret:"true"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Tracing owns the tracer provider, all methods are safe to call on the nil
+// *Tracing returned when tracing is disabled.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+}
+
+func init() {
+	RegisterSpanExporter(TracingExporterOTLP, newOTLPExporter)
+	RegisterSpanExporter(TracingExporterStdout, func(ctx context.Context, opt TracingOptions) (sdktrace.SpanExporter, error) {
+		return stdouttrace.New()
+	})
+	RegisterSpanExporter(TracingExporterFile, func(ctx context.Context, opt TracingOptions) (sdktrace.SpanExporter, error) {
+		file, err := os.OpenFile(opt.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	})
+}
+
+// RegisterSpanExporter makes an exporter available to TracingOptions.Exporter,
+// it must be called before InitTracing.
+func RegisterSpanExporter(name string, factory SpanExporterFactory) {
+	spanExportersMu.Lock()
+	defer spanExportersMu.Unlock()
+
+	spanExporters[name] = factory
+}
+
+func IsSpanExporter(name string) bool {
+	spanExportersMu.RLock()
+	defer spanExportersMu.RUnlock()
+
+	_, ok := spanExporters[name]
+
+	return ok
+}
+
+func InitTracing(log zerolog.Logger, opt TracingOptions) *Tracing {
+	var t *Tracing
+
+	if !opt.Enabled {
+		return nil
+	}
+
+	onceTracing.Do(func() {
+		if opt.ServiceName == "" {
+			opt.ServiceName = defaultTracingServiceName
+		}
+
+		if opt.SampleRatio <= 0 {
+			opt.SampleRatio = defaultTracingSampleRatio
+		}
+
+		spanExportersMu.RLock()
+		factory, ok := spanExporters[opt.Exporter]
+		spanExportersMu.RUnlock()
+
+		if !ok {
+			log.Panic().Str("exporter", opt.Exporter).Msg("Unknown span exporter")
+		}
+
+		exporter, err := factory(context.Background(), opt)
+		if err != nil {
+			log.Panic().Err(err).Str("exporter", opt.Exporter).Msg("Failed to create span exporter")
+		}
+
+		res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", opt.ServiceName)))
+		if err != nil {
+			log.Panic().Err(err).Send()
+		}
+
+		t = &Tracing{
+			provider: sdktrace.NewTracerProvider(
+				sdktrace.WithBatcher(exporter),
+				sdktrace.WithResource(res),
+				sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opt.SampleRatio))),
+			),
+		}
+
+		otel.SetTracerProvider(t.provider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+		log.Debug().Str("exporter", opt.Exporter).Float64("sample_ratio", opt.SampleRatio).Msg("Tracing configured")
+	})
+
+	return t
+}
+
+// Shutdown flushes the spans still buffered by the batcher.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	return t.provider.Shutdown(ctx)
+}
+
+func newOTLPExporter(ctx context.Context, opt TracingOptions) (sdktrace.SpanExporter, error) {
+	options := make([]otlptracehttp.Option, 0)
+
+	if opt.OTLP.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(opt.OTLP.Endpoint))
+	}
+
+	if opt.OTLP.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	if opt.OTLP.Timeout > 0 {
+		options = append(options, otlptracehttp.WithTimeout(opt.OTLP.Timeout))
+	}
+
+	if len(opt.OTLP.Headers) > 0 {
+		headers := make(map[string]string, len(opt.OTLP.Headers))
+		for _, header := range opt.OTLP.Headers {
+			key, value, found := strings.Cut(header, "=")
+			if !found {
+				return nil, fmt.Errorf("otlp header %q is not key=value", key)
+			}
+
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+
+		options = append(options, otlptracehttp.WithHeaders(headers))
+	}
+
+	return otlptracehttp.New(ctx, options...)
+}
+
+// StartSpan starts a child of the span of ctx, it is a no-op when tracing is disabled.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error on the span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// startRootSpan starts the span of a request or a job and aligns the trace
+// context of ctx with it, so the logs and the outgoing calls carry its IDs.
+func startRootSpan(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+
+	// a remote span context means tracing is disabled and the caller's one was returned
+	if sc := span.SpanContext(); sc.IsValid() && !sc.IsRemote() {
+		ctx = context.WithValue(ctx, preference.CONTEXT_KEY_TRACE_PARENT, TraceParent{
+			Version:  "00",
+			TraceID:  sc.TraceID().String(),
+			ParentID: sc.SpanID().String(),
+			Flags:    sc.TraceFlags().String(),
+		})
+	}
+
+	return ctx, span
+}
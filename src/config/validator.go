@@ -1,14 +1,36 @@
 package config
 
 import (
+	"errors"
+	"reflect"
 	"regexp"
+	"strings"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
 
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
 	"github.com/rs/zerolog"
 )
 
-var passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!@#\$%\^&\*]{8,}$`)
+var (
+	passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!@#\$%\^&\*]{8,}$`)
+
+	// validationTranslator is nil until InitValidator, the messages of the
+	// validator are used as is meanwhile
+	validationTranslator *ut.UniversalTranslator
+
+	passwordMessages = map[string]string{
+		preference.LANG_EN: "{0} must be at least 8 letters, digits or !@#$%^&* characters",
+		preference.LANG_ID: "{0} harus berisi minimal 8 karakter huruf, angka atau !@#$%^&*",
+	}
+)
 
 func InitValidator(log zerolog.Logger) {
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
@@ -18,9 +40,107 @@ func InitValidator(log zerolog.Logger) {
 		} else {
 			log.Debug().Msg("Custom password validator loaded successfully")
 		}
+
+		v.RegisterTagNameFunc(fieldName)
+
+		translator := ut.New(en.New(), en.New(), id.New())
+		if err := registerValidationTranslations(v, translator); err != nil {
+			log.Panic().Err(err).Msg("Failed to load validation translations")
+		}
+
+		validationTranslator = translator
 	}
 }
 
 func passwordValidator(fl validator.FieldLevel) bool {
 	return passwordRegex.MatchString(fl.Field().String())
 }
+
+// fieldName reports the fields by the name the client sent, json for the
+// bodies and form for the query parameters.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func registerValidationTranslations(v *validator.Validate, translator *ut.UniversalTranslator) error {
+	enTrans, _ := translator.GetTranslator(preference.LANG_EN)
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+
+	idTrans, _ := translator.GetTranslator(preference.LANG_ID)
+	if err := id_translations.RegisterDefaultTranslations(v, idTrans); err != nil {
+		return err
+	}
+
+	for lang, trans := range map[string]ut.Translator{preference.LANG_EN: enTrans, preference.LANG_ID: idTrans} {
+		err := v.RegisterTranslation("password", trans, func(ut ut.Translator) error {
+			return ut.Add("password", passwordMessages[lang], true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			msg, _ := ut.T("password", fe.Field())
+			return msg
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidationErrors lists the failed rules of a binding error, nil when the
+// error did not come from the validator.
+func ValidationErrors(err error, lang string) []exception.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(exception.RootCause(err), &validationErrs) {
+		return nil
+	}
+
+	var trans ut.Translator
+	if validationTranslator != nil {
+		trans, _ = validationTranslator.GetTranslator(lang)
+	}
+
+	fields := make([]exception.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		message := fe.Error()
+		if trans != nil {
+			message = fe.Translate(trans)
+		}
+
+		fields = append(fields, exception.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message,
+		})
+	}
+
+	return fields
+}
+
+// fieldPath drops the struct name from the namespace, e.g. address.city.
+func fieldPath(fe validator.FieldError) string {
+	if _, path, found := strings.Cut(fe.Namespace(), "."); found {
+		return path
+	}
+
+	return fe.Field()
+}
+
+// IsValidationError tells a body that failed the rules from a body that could not be decoded.
+func IsValidationError(err error) bool {
+	var validationErrs validator.ValidationErrors
+	return errors.As(exception.RootCause(err), &validationErrs)
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	exception "learngolang/src/errors"
+	"learngolang/src/preference"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+type validationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validationRequest struct {
+	Username string            `json:"username,omitempty" validate:"required"`
+	Password string            `json:"password" validate:"password"`
+	Page     int               `form:"page" validate:"min=1"`
+	Internal string            `json:"-"`
+	Note     string            `validate:"max=3"`
+	Address  validationAddress `json:"address"`
+}
+
+func newTestValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+
+	v := validator.New()
+	if err := v.RegisterValidation("password", passwordValidator); err != nil {
+		t.Fatal(err)
+	}
+
+	v.RegisterTagNameFunc(fieldName)
+
+	translator := ut.New(en.New(), en.New(), id.New())
+	if err := registerValidationTranslations(v, translator); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := validationTranslator
+	validationTranslator = translator
+	t.Cleanup(func() { validationTranslator = previous })
+
+	return v
+}
+
+func TestValidationErrors(t *testing.T) {
+	v := newTestValidator(t)
+
+	err := v.Struct(validationRequest{Password: "short", Note: "too long"})
+	if err == nil {
+		t.Fatal("Struct() error = nil, want validation errors")
+	}
+
+	english := []exception.FieldError{
+		{Field: "username", Rule: "required", Message: "username is a required field"},
+		{Field: "password", Rule: "password", Message: "password must be at least 8 letters, digits or !@#$%^&* characters"},
+		{Field: "page", Rule: "min", Param: "1", Message: "page must be 1 or greater"},
+		{Field: "Note", Rule: "max", Param: "3", Message: "Note must be a maximum of 3 characters in length"},
+		{Field: "address.city", Rule: "required", Message: "city is a required field"},
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		lang string
+		want []exception.FieldError
+	}{
+		{
+			name: "english",
+			err:  err,
+			lang: preference.LANG_EN,
+			want: english,
+		},
+		{
+			name: "indonesian",
+			err:  err,
+			lang: preference.LANG_ID,
+			want: []exception.FieldError{
+				{Field: "username", Rule: "required", Message: "username wajib diisi"},
+				{Field: "password", Rule: "password", Message: "password harus berisi minimal 8 karakter huruf, angka atau !@#$%^&*"},
+				{Field: "page", Rule: "min", Param: "1", Message: "page harus 1 atau lebih besar"},
+				{Field: "Note", Rule: "max", Param: "3", Message: "panjang maksimal Note adalah 3 karakter"},
+				{Field: "address.city", Rule: "required", Message: "city wajib diisi"},
+			},
+		},
+		{
+			name: "wrapped error",
+			err:  exception.WrapWithCode(err, exception.CodeHTTPValidatorError, "invalid body"),
+			lang: preference.LANG_EN,
+			want: english,
+		},
+		{
+			name: "not a validation error",
+			err:  errors.New("unexpected EOF"),
+			lang: preference.LANG_EN,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidationErrors(tt.err, tt.lang); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidationErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	typ := reflect.TypeOf(validationRequest{})
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "Username", want: "username"},
+		{field: "Page", want: "page"},
+		{field: "Internal", want: ""},
+		{field: "Note", want: "Note"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := typ.FieldByName(tt.field)
+			if got := fieldName(field); got != tt.want {
+				t.Errorf("fieldName(%s) = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package dto
+
+// admin related DTOs
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=trace debug info warn error fatal panic disabled"`
+	// Component overrides the level of a single component, the base level is changed when empty
+	Component string `json:"component" binding:"omitempty,oneof=repository auth scheduler middleware"`
+}
+
+type LogLevelResponse struct {
+	Level      string            `json:"level" extensions:"x-order=0"`
+	Components map[string]string `json:"components" extensions:"x-order=1"`
+}
+
+type PurgeCacheResponse struct {
+	Deleted int64 `json:"deleted" extensions:"x-order=0"`
+}
+
+type SchedulerStatusResponse struct {
+	Paused bool     `json:"paused" extensions:"x-order=0"`
+	Jobs   []string `json:"jobs" extensions:"x-order=1"`
+}
+
+type ConfigReloadResponse struct {
+	Changed []string `json:"changed" extensions:"x-order=0"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type HealthCheck struct {
+	Status  string  `json:"status" extensions:"x-order=0"`
+	Latency float64 `json:"latency_ms" extensions:"x-order=1"`
+	Error   string  `json:"error,omitempty" extensions:"x-order=2"`
+}
+
+type HealthResp struct {
+	Status    string                 `json:"status" extensions:"x-order=0"`
+	Checks    map[string]HealthCheck `json:"checks,omitempty" extensions:"x-order=1"`
+	Timestamp string                 `json:"timestamp" extensions:"x-order=2"`
+}
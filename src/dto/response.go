@@ -11,6 +11,7 @@ type Meta struct {
 	Message    string      `json:"message" extensions:"x-order=3"`
 	Error      *x.AppError `json:"error,omitempty" swaggertype:"primitive,object" extensions:"x-order=4"`
 	Timestamp  string      `json:"timestamp" extensions:"x-order=5"`
+	RequestID  string      `json:"request_id,omitempty" extensions:"x-order=6"`
 }
 
 type HttpSuccessResp struct {
@@ -22,3 +23,17 @@ type HttpSuccessResp struct {
 type HTTPErrorResp struct {
 	Meta Meta `json:"metadata"`
 }
+
+// ProblemDetails is the RFC 7807 error body, sent as application/problem+json
+// when the client accepts it or the config selects it.
+type ProblemDetails struct {
+	Type      string         `json:"type" extensions:"x-order=0"`
+	Title     string         `json:"title" extensions:"x-order=1"`
+	Status    int            `json:"status" extensions:"x-order=2"`
+	Detail    string         `json:"detail,omitempty" extensions:"x-order=3"`
+	Instance  string         `json:"instance,omitempty" extensions:"x-order=4"`
+	Code      x.Code         `json:"code" extensions:"x-order=5"`
+	RequestID string         `json:"request_id,omitempty" extensions:"x-order=6"`
+	Debug     *string        `json:"debug,omitempty" extensions:"x-order=7"`
+	Errors    []x.FieldError `json:"errors,omitempty" extensions:"x-order=8"`
+}
@@ -0,0 +1,216 @@
+package errors
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"learngolang/src/preference"
+
+	"github.com/goccy/go-yaml"
+)
+
+const messageNotDefined string = "error message not defined!"
+
+// Catalog holds the messages of one locale, by service name then by message key.
+type Catalog map[string]map[string]string
+
+type catalogSet struct {
+	locales  map[string]Catalog
+	fallback string
+}
+
+// catalogs is empty until SetCatalogs, Compile returns messageNotDefined meanwhile
+var catalogs atomic.Pointer[catalogSet]
+
+func init() {
+	RegisterService(COMMON, "common", ErrorMessages)
+	catalogs.Store(&catalogSet{locales: map[string]Catalog{}, fallback: preference.LANG_ID})
+}
+
+// RegisterService adds the messages of a service, its texts are read from the
+// section of the same name in the catalogs, then from the common one.
+func RegisterService(service ServiceType, name string, messages ErrorMessage) {
+	svcError[service] = messages
+	serviceNames[service] = name
+}
+
+// ReadCatalogs loads every <locale>.yaml, .yml or .json file of dir, json
+// being yaml the same parser reads both.
+func ReadCatalogs(dir string) (map[string]Catalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	locales := make(map[string]Catalog)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || !slices.Contains([]string{".yaml", ".yml", ".json"}, ext) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog Catalog
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("message catalog %s: %w", entry.Name(), err)
+		}
+
+		locale := normalizeLanguage(strings.TrimSuffix(entry.Name(), ext))
+		if _, ok := locales[locale]; ok {
+			return nil, fmt.Errorf("message catalog %s: locale %q is defined twice", entry.Name(), locale)
+		}
+
+		locales[locale] = catalog
+	}
+
+	return locales, nil
+}
+
+// SetCatalogs replaces the messages, fallback is the locale used when none of
+// the requested ones is available.
+func SetCatalogs(locales map[string]Catalog, fallback string) error {
+	fallback = normalizeLanguage(fallback)
+	if _, ok := locales[fallback]; !ok {
+		return fmt.Errorf("no message catalog for the default language %q", fallback)
+	}
+
+	catalogs.Store(&catalogSet{locales: locales, fallback: fallback})
+
+	return nil
+}
+
+// LintCatalogs lists the codes without a message and the messages missing
+// from a locale, the catalogs are complete when it is empty.
+func LintCatalogs(locales map[string]Catalog) []string {
+	problems := make([]string, 0)
+
+	services := slices.Sorted(maps.Keys(svcError))
+
+	for _, code := range Codes() {
+		defined := false
+		for _, service := range services {
+			if msg, ok := svcError[service][code]; ok {
+				defined = true
+				for _, locale := range slices.Sorted(maps.Keys(locales)) {
+					if _, ok := locales[locale].text(serviceNames[service], msg.Key); !ok {
+						problems = append(problems, fmt.Sprintf("%s: code %d has no %s.%s message", locale, code, serviceNames[service], msg.Key))
+					}
+				}
+			}
+		}
+
+		if !defined {
+			problems = append(problems, fmt.Sprintf("code %d has no message", code))
+		}
+	}
+
+	return slices.Compact(problems)
+}
+
+func (c Catalog) text(service string, key string) (string, bool) {
+	if text, ok := c[service][key]; ok {
+		return text, true
+	}
+
+	text, ok := c[serviceNames[COMMON]][key]
+
+	return text, ok
+}
+
+// Languages returns the locales of the loaded catalogs.
+func Languages() []string {
+	return slices.Sorted(maps.Keys(catalogs.Load().locales))
+}
+
+// MatchLanguage returns the first tag with a catalog, a regional tag also
+// matches its language, e.g. en-US matches en, the default language otherwise.
+func MatchLanguage(tags ...string) string {
+	set := catalogs.Load()
+
+	for _, tag := range tags {
+		tag = normalizeLanguage(tag)
+		if _, ok := set.locales[tag]; ok {
+			return tag
+		}
+
+		if base, _, found := strings.Cut(tag, "-"); found {
+			if _, ok := set.locales[base]; ok {
+				return base
+			}
+		}
+	}
+
+	return set.fallback
+}
+
+// ParseAcceptLanguage returns the tags of an Accept-Language header, most
+// preferred first, the ones with q=0 and the wildcard are left out.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	tags := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+
+	// stable, so the tags of the same quality keep the order of the header
+	slices.SortStableFunc(tags, func(a, b weighted) int {
+		return cmp.Compare(b.q, a.q)
+	})
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.tag
+	}
+
+	return languages
+}
+
+func normalizeLanguage(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// messageText returns the text of the message in the best locale for lang.
+func messageText(service ServiceType, msg Message, lang string) string {
+	set := catalogs.Load()
+
+	for _, locale := range []string{MatchLanguage(lang), set.fallback} {
+		if text, ok := set.locales[locale].text(serviceNames[service], msg.Key); ok {
+			return text
+		}
+	}
+
+	return messageNotDefined
+}
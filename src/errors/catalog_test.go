@@ -0,0 +1,62 @@
+package errors
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "en", want: []string{"en"}},
+		{header: "id-ID,id;q=0.9,en;q=0.8", want: []string{"id-ID", "id", "en"}},
+		{header: "en;q=0.5, id", want: []string{"id", "en"}},
+		{header: "fr;q=0.8,de;q=0.8,en", want: []string{"en", "fr", "de"}},
+		{header: "en;q=0,id", want: []string{"id"}},
+		{header: "*,en;q=0.1", want: []string{"en"}},
+		{header: "en;q=abc,id;q=0.3", want: []string{"id"}},
+		{header: " , ,en", want: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	previous := catalogs.Load()
+	t.Cleanup(func() { catalogs.Store(previous) })
+
+	if err := SetCatalogs(map[string]Catalog{"en": {}, "id": {}, "pt-br": {}}, "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "no tags", tags: nil, want: "en"},
+		{name: "exact", tags: []string{"id"}, want: "id"},
+		{name: "case and underscore", tags: []string{"PT_BR"}, want: "pt-br"},
+		{name: "region falls back to the language", tags: []string{"id-ID"}, want: "id"},
+		{name: "language does not match a region", tags: []string{"pt"}, want: "en"},
+		{name: "first available wins", tags: []string{"fr", "id", "en"}, want: "id"},
+		{name: "unknown", tags: []string{"fr", "de"}, want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchLanguage(tt.tags...); got != tt.want {
+				t.Errorf("MatchLanguage(%v) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,21 +4,22 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-
-	"learngolang/src/preference"
 )
 
 type AppError struct {
 	Code       Code    `json:"code"`
 	Message    string  `json:"message"`
 	DebugError *string `json:"debug,omitempty"`
-	sys        error
+	// Errors lists the fields that failed the validation
+	Errors []FieldError `json:"errors,omitempty"`
+	sys    error
 }
 
-func init() {
-	svcError = map[ServiceType]ErrorMessage{
-		COMMON: ErrorMessages,
-	}
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
 
 func Compile(service ServiceType, err error, lang string, debugMode bool) (int, AppError) {
@@ -34,14 +35,9 @@ func Compile(service ServiceType, err error, lang string, debugMode bool) (int,
 	code := ErrCode(err)
 
 	if errMessage, ok := svcError[COMMON][code]; ok {
-		msg := errMessage.ID
-		if lang == preference.LANG_EN {
-			msg = errMessage.EN
-		}
-
 		return errMessage.StatusCode, AppError{
 			Code:       code,
-			Message:    msg,
+			Message:    messageText(COMMON, errMessage, lang),
 			sys:        err,
 			DebugError: debugErr,
 		}
@@ -49,10 +45,7 @@ func Compile(service ServiceType, err error, lang string, debugMode bool) (int,
 
 	if errMessages, ok := svcError[service]; ok {
 		if errMessage, ok := errMessages[code]; ok {
-			msg := errMessage.ID
-			if lang == preference.LANG_EN {
-				msg = errMessage.EN
-			}
+			msg := messageText(service, errMessage, lang)
 
 			if errMessage.HasAnnotation {
 				args := fmt.Sprintf("%q", err.Error())
@@ -85,7 +78,7 @@ func Compile(service ServiceType, err error, lang string, debugMode bool) (int,
 
 		return http.StatusInternalServerError, AppError{
 			Code:       code,
-			Message:    "error message not defined!",
+			Message:    messageNotDefined,
 			sys:        err,
 			DebugError: debugErr,
 		}
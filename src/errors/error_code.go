@@ -3,7 +3,8 @@ package errors
 import "github.com/palantir/stacktrace"
 
 var (
-	svcError map[ServiceType]ErrorMessage
+	svcError     = map[ServiceType]ErrorMessage{}
+	serviceNames = map[ServiceType]string{}
 
 	ErrCode      = stacktrace.GetCode
 	New          = stacktrace.NewError
@@ -19,9 +20,9 @@ type (
 	ErrorMessage map[Code]Message
 
 	Message struct {
-		StatusCode    int    `json:"status_code"`
-		EN            string `json:"en"`
-		ID            string `json:"id"`
+		StatusCode int `json:"status_code"`
+		// Key names the text in the message catalogs, e.g. not_found
+		Key           string `json:"key"`
 		HasAnnotation bool
 	}
 )
@@ -30,6 +31,27 @@ const (
 	COMMON ServiceType = 1
 )
 
+// codeRanges holds the first and the last code of every block below, a new
+// code must be added to its block before the lint can see it.
+var codeRanges = [][2]Code{
+	{CodeHTTPBadRequest, CodeHTTPErrorOnReadBody},
+	{CodeSQLBuilder, CodeSQLQueryBuild},
+	{CodeTokenStillValid, CodeTokenRefreshStillValid},
+	{CodeCacheMarshal, CodeCacheNotFound},
+}
+
+// Codes returns every defined code.
+func Codes() []Code {
+	codes := make([]Code, 0)
+	for _, r := range codeRanges {
+		for code := r[0]; code <= r[1]; code++ {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
 const (
 	// Code HTTP Handler
 	CodeHTTPBadRequest = Code(iota + 100)
@@ -13,6 +13,7 @@ var ErrorMessages = ErrorMessage{
 	CodeHTTPForbidden:           ErrMsgForbidden,
 	CodeHTTPUnprocessableEntity: ErrMsgUnprocessable,
 	CodeHTTPTooManyRequest:      ErrMsgTooManyRequest,
+	CodeHTTPValidatorError:      ErrMsgValidation,
 	CodeHTTPServiceUnavailable:  ErrMsgServiceUnavailable,
 	CodeHTTPParamDecode:         ErrMsgBadRequest,
 	CodeHTTPErrorOnReadBody:     ErrMsgISE,
@@ -35,70 +36,83 @@ var ErrorMessages = ErrorMessage{
 	CodeSQLRecordIsExpired:            ErrMsgBadRequest,
 	CodeSQLRecordDoesNotExist:         ErrMsgNotFound,
 	CodeSQLForeignKeyMissing:          ErrMsgISE,
+	CodeSQLTxRollback:                 ErrMsgISE,
+	CodeRequestIDIsNotMatch:           ErrMsgBadRequest,
+	CodeSQLConflict:                   ErrMsgConflict,
+	CodeSQLEmptyRow:                   ErrMsgNotFound,
+	CodeSQLTableNotExist:              ErrMsgISE,
+	CodeSQLQueryBuild:                 ErrMsgISE,
 
 	CodeTokenStillValid:        ErrMsgTokenStillValid,
 	CodeTokenRefreshStillValid: ErrMsgRefreshStillValid,
+
+	CodeCacheMarshal:         ErrMsgISE,
+	CodeCacheUnmarshal:       ErrMsgISE,
+	CodeCacheGetSimpleKey:    ErrMsgISE,
+	CodeCacheSetSimpleKey:    ErrMsgISE,
+	CodeCacheDeleteSimpleKey: ErrMsgISE,
+	CodeCacheGetHashKey:      ErrMsgISE,
+	CodeCacheSetHashKey:      ErrMsgISE,
+	CodeCacheDeleteHashKey:   ErrMsgISE,
+	CodeCacheSetExpiration:   ErrMsgISE,
+	CodeCacheDecode:          ErrMsgISE,
+	CodeCacheLockNotAcquired: ErrMsgConflict,
+	CodeCacheLockFailed:      ErrMsgISE,
+	CodeCacheInvalidCastType: ErrMsgISE,
+	CodeCacheNotFound:        ErrMsgNotFound,
 }
 
 var (
 	ErrMsgBadRequest = Message{
 		StatusCode: http.StatusBadRequest,
-		EN:         `Invalid Input. Please Validate Your Input.`,
-		ID:         `Kesalahan Input. Mohon Cek Kembali Masukkan Anda.`,
+		Key:        `bad_request`,
+	}
+	ErrMsgValidation = Message{
+		StatusCode: http.StatusBadRequest,
+		Key:        `validation`,
 	}
 	ErrMsgNotFound = Message{
 		StatusCode: http.StatusNotFound,
-		EN:         `Record Does Not Exist. Please Validate Your Input Or Contact Administrator.`,
-		ID:         `Data Tidak Ditemukan. Mohon Cek Kembali Masukkan Anda Atau Hubungi Administrator.`,
+		Key:        `not_found`,
 	}
 	ErrMsgUnauthorized = Message{
 		StatusCode: http.StatusUnauthorized,
-		EN:         `Unauthorized Access. You are not authorized to access this resource.`,
-		ID:         `Akses Ditolak. Anda Belum Diijinkan Untuk Mengakses Aplikasi.`,
+		Key:        `unauthorized`,
 	}
 	ErrMsgISE = Message{
 		StatusCode: http.StatusInternalServerError,
-		EN:         `Internal Server Error. Please Call Administrator.`,
-		ID:         `Terjadi Kendala Pada Server. Mohon Hubungi Administrator.`,
+		Key:        `internal_server_error`,
 	}
 	ErrMsgConflict = Message{
 		StatusCode: http.StatusConflict,
-		EN:         `Record has existed and must be unique. Please Validate Your Input Or Contact Administrator.`,
-		ID:         `Data sudah ada. Mohon Cek Kembali Masukkan Anda Atau Hubungi Administrator.`,
+		Key:        `conflict`,
 	}
 	ErrMsgForbidden = Message{
 		StatusCode: http.StatusForbidden,
-		EN:         `Forbidden. You don't have permission to access this resource.`,
-		ID:         `Terlarang. Anda tidak memiliki izin untuk mengakses aplikasi.`,
+		Key:        `forbidden`,
 	}
 	ErrMsgUnprocessable = Message{
 		StatusCode: http.StatusUnprocessableEntity,
-		EN:         `Not Able to Process This Entity. Please Validate Your Input and Try Again`,
-		ID:         `Entitas Ini Tidak Dapat Diproses. Mohon Cek Kembali Masukkan Anda Dan Coba Kembali`,
+		Key:        `unprocessable_entity`,
 	}
 	ErrMsgTooManyRequest = Message{
 		StatusCode: http.StatusTooManyRequests,
-		EN:         `Too Many Request For This Entity. Please Wait And Try Again.`,
-		ID:         `Permintaan Terlalu Banyak Untuk Entitas Ini. Mohon Tunggu Dan Coba Kembali.`,
+		Key:        `too_many_requests`,
 	}
 	ErrMsgServiceUnavailable = Message{
 		StatusCode: http.StatusServiceUnavailable,
-		EN:         `Service is unavailable.`,
-		ID:         `Layanan sedang tidak tersedia.`,
+		Key:        `service_unavailable`,
 	}
 	ErrMsgUniqueConst = Message{
 		StatusCode: http.StatusConflict,
-		EN:         `Record Has Existed and Must Be Unique. Please Validate Your Input Or Contact Administrator.`,
-		ID:         `Data sudah ada. Mohon Cek Kembali Masukkan Anda Atau Hubungi Administrator.`,
+		Key:        `unique_constraint`,
 	}
 	ErrMsgTokenStillValid = Message{
 		StatusCode: http.StatusForbidden,
-		EN:         `Token still valid. Please Validate Your Input Or Contact Administrator.`,
-		ID:         `Token masih valid. Mohon Cek Kembali Masukkan Anda Atau Hubungi Administrator.`,
+		Key:        `token_still_valid`,
 	}
 	ErrMsgRefreshStillValid = Message{
 		StatusCode: http.StatusForbidden,
-		EN:         `Refresh token still valid. Please Validate Your Input Or Contact Administrator.`,
-		ID:         `Refresh token masih valid. Mohon Cek Kembali Masukkan Anda Atau Hubungi Administrator.`,
+		Key:        `refresh_token_still_valid`,
 	}
 )
@@ -0,0 +1,78 @@
+package errors
+
+// ProblemTypeBaseURI prefixes the RFC 7807 problem types, the part after it is
+// part of the API contract and must not change once released.
+const ProblemTypeBaseURI = "urn:learngolang:problem:"
+
+// ProblemTypeBlank is the RFC 7807 type of an error without a known code, the
+// status code alone describes it.
+const ProblemTypeBlank = "about:blank"
+
+var problemTypes = map[Code]string{
+	CodeHTTPBadRequest:          "bad-request",
+	CodeHTTPNotFound:            "not-found",
+	CodeHTTPUnauthorized:        "unauthorized",
+	CodeHTTPInternalServerError: "internal-server-error",
+	CodeHTTPUnmarshal:           "invalid-request-body",
+	CodeHTTPMarshal:             "response-encoding-failed",
+	CodeHTTPConflict:            "conflict",
+	CodeHTTPForbidden:           "forbidden",
+	CodeHTTPUnprocessableEntity: "unprocessable-entity",
+	CodeHTTPTooManyRequest:      "too-many-requests",
+	CodeHTTPValidatorError:      "validation-failed",
+	CodeHTTPServiceUnavailable:  "service-unavailable",
+	CodeHTTPParamDecode:         "invalid-parameter",
+	CodeHTTPErrorOnReadBody:     "request-body-unreadable",
+
+	CodeSQLBuilder:                    "sql-builder",
+	CodeSQLRead:                       "sql-read",
+	CodeSQLRowScan:                    "sql-row-scan",
+	CodeSQLCreate:                     "sql-create",
+	CodeSQLUpdate:                     "sql-update",
+	CodeSQLDelete:                     "sql-delete",
+	CodeSQLUnlink:                     "sql-unlink",
+	CodeSQLTxBegin:                    "sql-tx-begin",
+	CodeSQLTxCommit:                   "sql-tx-commit",
+	CodeSQLPrepareStmt:                "sql-prepare-statement",
+	CodeSQLRecordMustExist:            "record-must-exist",
+	CodeSQLCannotRetrieveLastInsertID: "sql-last-insert-id",
+	CodeSQLCannotRetrieveAffectedRows: "sql-affected-rows",
+	CodeSQLUniqueConstraint:           "unique-constraint",
+	CodeSQLRecordDoesNotMatch:         "record-does-not-match",
+	CodeSQLRecordIsExpired:            "record-expired",
+	CodeSQLRecordDoesNotExist:         "record-does-not-exist",
+	CodeSQLForeignKeyMissing:          "foreign-key-missing",
+	CodeSQLTxRollback:                 "sql-tx-rollback",
+	CodeRequestIDIsNotMatch:           "request-id-mismatch",
+	CodeSQLConflict:                   "sql-conflict",
+	CodeSQLEmptyRow:                   "record-not-found",
+	CodeSQLTableNotExist:              "sql-table-not-exist",
+	CodeSQLQueryBuild:                 "sql-query-build",
+
+	CodeTokenStillValid:        "token-still-valid",
+	CodeTokenRefreshStillValid: "refresh-token-still-valid",
+
+	CodeCacheMarshal:         "cache-marshal",
+	CodeCacheUnmarshal:       "cache-unmarshal",
+	CodeCacheGetSimpleKey:    "cache-get",
+	CodeCacheSetSimpleKey:    "cache-set",
+	CodeCacheDeleteSimpleKey: "cache-delete",
+	CodeCacheGetHashKey:      "cache-hash-get",
+	CodeCacheSetHashKey:      "cache-hash-set",
+	CodeCacheDeleteHashKey:   "cache-hash-delete",
+	CodeCacheSetExpiration:   "cache-set-expiration",
+	CodeCacheDecode:          "cache-decode",
+	CodeCacheLockNotAcquired: "cache-lock-not-acquired",
+	CodeCacheLockFailed:      "cache-lock-failed",
+	CodeCacheInvalidCastType: "cache-invalid-cast-type",
+	CodeCacheNotFound:        "cache-not-found",
+}
+
+// ProblemType returns the stable problem type URI of the code.
+func ProblemType(code Code) string {
+	if slug, ok := problemTypes[code]; ok {
+		return ProblemTypeBaseURI + slug
+	}
+
+	return ProblemTypeBlank
+}
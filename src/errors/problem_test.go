@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProblemTypeCoversEveryCode(t *testing.T) {
+	seen := make(map[string]Code)
+
+	for _, code := range Codes() {
+		got := ProblemType(code)
+		if !strings.HasPrefix(got, ProblemTypeBaseURI) {
+			t.Errorf("ProblemType(%d) = %q, want a %s URN", code, got, ProblemTypeBaseURI)
+			continue
+		}
+
+		if previous, ok := seen[got]; ok {
+			t.Errorf("ProblemType(%d) = %q, already used by %d", code, got, previous)
+		}
+
+		seen[got] = code
+	}
+
+	if len(problemTypes) != len(Codes()) {
+		t.Errorf("problemTypes has %d entries, want one per code (%d)", len(problemTypes), len(Codes()))
+	}
+}
+
+func TestProblemType(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want string
+	}{
+		{name: "http", code: CodeHTTPNotFound, want: "urn:learngolang:problem:not-found"},
+		{name: "sql", code: CodeSQLUniqueConstraint, want: "urn:learngolang:problem:unique-constraint"},
+		{name: "unknown", code: Code(9999), want: ProblemTypeBlank},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProblemType(tt.code); got != tt.want {
+				t.Errorf("ProblemType(%d) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
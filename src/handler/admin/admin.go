@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"sync"
+
+	"learngolang/src/config"
+	"learngolang/src/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+var onceAdminHandler = &sync.Once{}
+
+type admin struct {
+	gin       *gin.Engine
+	log       zerolog.Logger
+	mw        config.Middleware
+	svc       *service.Service
+	scheduler *config.Scheduler
+	reloader  *config.Reloader
+}
+
+func InitAdminHandler(gin *gin.Engine, log zerolog.Logger, mw config.Middleware, svc *service.Service, scheduler *config.Scheduler, reloader *config.Reloader) {
+	var e *admin
+
+	if gin == nil {
+		return
+	}
+
+	onceAdminHandler.Do(func() {
+		e = &admin{
+			gin:       gin,
+			log:       log,
+			mw:        mw,
+			svc:       svc,
+			scheduler: scheduler,
+			reloader:  reloader,
+		}
+
+		e.Serve()
+	})
+}
+
+func (e *admin) Serve() {
+	// Logger
+	e.gin.GET("/log/level", e.GetLogLevel)
+	e.gin.PUT("/log/level", e.SetLogLevel)
+	e.gin.DELETE("/log/level/:component", e.ResetComponentLogLevel)
+
+	// Config
+	e.gin.POST("/config/reload", e.ReloadConfig)
+
+	// Cache
+	e.gin.DELETE("/cache/users", e.PurgeUserCache)
+
+	// Scheduler
+	e.gin.GET("/scheduler", e.GetSchedulerStatus)
+	e.gin.POST("/scheduler/pause", e.PauseScheduler)
+	e.gin.POST("/scheduler/resume", e.ResumeScheduler)
+	e.gin.POST("/scheduler/jobs/:name/trigger", e.TriggerJob)
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"net/http"
+
+	"learngolang/src/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func (e *admin) PurgeUserCache(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deleted, err := e.svc.User.PurgeCache(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("purge_user_cache")
+		e.httpRespError(c, err)
+		return
+	}
+
+	e.log.Warn().Int64("deleted", deleted).Msg("user_cache_purged")
+	e.httpRespSuccess(c, http.StatusOK, dto.PurgeCacheResponse{Deleted: deleted})
+}
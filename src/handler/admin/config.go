@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"net/http"
+
+	"learngolang/src/dto"
+	exception "learngolang/src/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *admin) ReloadConfig(c *gin.Context) {
+	changed, err := e.reloader.Reload(c.Request.Context())
+	if err != nil {
+		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPUnprocessableEntity, "config_reload_rejected"))
+		return
+	}
+
+	if changed == nil {
+		changed = []string{}
+	}
+
+	e.httpRespSuccess(c, http.StatusOK, dto.ConfigReloadResponse{Changed: changed})
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"learngolang/src/config"
+	"learngolang/src/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *admin) httpRespSuccess(c *gin.Context, statusCode int, resp any) {
+	meta := dto.Meta{
+		Path:       c.Request.URL.Path,
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Message:    fmt.Sprintf("%s %s [%d] %s", c.Request.Method, c.Request.RequestURI, statusCode, http.StatusText(statusCode)),
+		Error:      nil,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		RequestID:  config.RequestIDFromContext(c.Request.Context()),
+	}
+
+	c.JSON(statusCode, &dto.HttpSuccessResp{
+		Meta: meta,
+		Data: resp,
+	})
+}
+
+func (e *admin) httpRespError(c *gin.Context, appErr error) {
+	e.mw.AbortWithError(c, appErr)
+}
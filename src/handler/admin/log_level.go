@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"net/http"
+
+	"learngolang/src/config"
+	"learngolang/src/dto"
+	exception "learngolang/src/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func (e *admin) GetLogLevel(c *gin.Context) {
+	e.httpRespSuccess(c, http.StatusOK, logLevelResponse())
+}
+
+func (e *admin) SetLogLevel(c *gin.Context) {
+	var req dto.LogLevelRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPUnmarshal, "invalid_request_body"))
+		return
+	}
+
+	level, err := zerolog.ParseLevel(req.Level)
+	if err != nil {
+		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPBadRequest, "invalid_log_level"))
+		return
+	}
+
+	if req.Component != "" {
+		if err := config.SetComponentLogLevel(req.Component, level); err != nil {
+			e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPBadRequest, "invalid_log_component"))
+			return
+		}
+
+		e.log.Warn().Str("component", req.Component).Str("level", level.String()).Msg("log_level_changed")
+		e.httpRespSuccess(c, http.StatusOK, logLevelResponse())
+		return
+	}
+
+	previous, _ := config.LogLevel()
+	config.SetLogLevel(level)
+
+	e.log.Warn().Str("previous", previous.String()).Str("level", level.String()).Msg("log_level_changed")
+	e.httpRespSuccess(c, http.StatusOK, logLevelResponse())
+}
+
+func (e *admin) ResetComponentLogLevel(c *gin.Context) {
+	component := c.Param("component")
+
+	if err := config.ResetComponentLogLevel(component); err != nil {
+		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPNotFound, "invalid_log_component"))
+		return
+	}
+
+	e.log.Warn().Str("component", component).Msg("log_level_reset")
+	e.httpRespSuccess(c, http.StatusOK, logLevelResponse())
+}
+
+func logLevelResponse() dto.LogLevelResponse {
+	base, overrides := config.LogLevel()
+
+	components := make(map[string]string, len(overrides))
+	for component, level := range overrides {
+		components[component] = level.String()
+	}
+
+	return dto.LogLevelResponse{Level: base.String(), Components: components}
+}
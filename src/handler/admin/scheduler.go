@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"net/http"
+
+	"learngolang/src/dto"
+	exception "learngolang/src/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *admin) GetSchedulerStatus(c *gin.Context) {
+	if !e.schedulerEnabled(c) {
+		return
+	}
+
+	e.httpRespSuccess(c, http.StatusOK, e.schedulerStatus())
+}
+
+func (e *admin) PauseScheduler(c *gin.Context) {
+	if !e.schedulerEnabled(c) {
+		return
+	}
+
+	e.scheduler.Pause()
+	e.httpRespSuccess(c, http.StatusOK, e.schedulerStatus())
+}
+
+func (e *admin) ResumeScheduler(c *gin.Context) {
+	if !e.schedulerEnabled(c) {
+		return
+	}
+
+	e.scheduler.Resume()
+	e.httpRespSuccess(c, http.StatusOK, e.schedulerStatus())
+}
+
+func (e *admin) TriggerJob(c *gin.Context) {
+	if !e.schedulerEnabled(c) {
+		return
+	}
+
+	if err := e.scheduler.Trigger(c.Param("name")); err != nil {
+		e.httpRespError(c, err)
+		return
+	}
+
+	e.httpRespSuccess(c, http.StatusAccepted, nil)
+}
+
+func (e *admin) schedulerEnabled(c *gin.Context) bool {
+	if e.scheduler == nil {
+		e.httpRespError(c, exception.NewWithCode(exception.CodeHTTPServiceUnavailable, "scheduler_disabled"))
+		return false
+	}
+
+	return true
+}
+
+func (e *admin) schedulerStatus() dto.SchedulerStatusResponse {
+	return dto.SchedulerStatusResponse{
+		Paused: e.scheduler.IsPaused(),
+		Jobs:   e.scheduler.ListJobs(),
+	}
+}
@@ -5,9 +5,9 @@ import (
 	"net/http"
 	"time"
 
+	"learngolang/src/config"
 	"learngolang/src/dto"
 	exception "learngolang/src/errors"
-	"learngolang/src/preference"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +20,7 @@ func (e *rest) httpRespSuccess(c *gin.Context, statusCode int, resp any, p *dto.
 		Message:    fmt.Sprintf("%s %s [%d] %s", c.Request.Method, c.Request.RequestURI, statusCode, http.StatusText(statusCode)),
 		Error:      nil,
 		Timestamp:  time.Now().Format(time.RFC3339),
+		RequestID:  config.RequestIDFromContext(c.Request.Context()),
 	}
 
 	HttpResp := &dto.HttpSuccessResp{
@@ -32,14 +33,16 @@ func (e *rest) httpRespSuccess(c *gin.Context, statusCode int, resp any, p *dto.
 }
 
 func (e *rest) httpRespError(c *gin.Context, appErr error) {
-	lang := preference.LANG_ID
+	statusCode, displayError := e.mw.CompileError(c, appErr)
 
-	appLangHeader := http.CanonicalHeaderKey(preference.APP_LANG)
-	if c.Request.Header[appLangHeader] != nil && c.Request.Header[appLangHeader][0] == preference.LANG_EN {
-		lang = preference.LANG_EN
+	// the access log reads the error code from here
+	_ = c.Error(appErr)
+
+	if e.mw.UseProblemJSON(c) {
+		config.WriteProblemJSON(c, statusCode, config.NewProblemDetails(c, statusCode, displayError))
+		return
 	}
 
-	statusCode, displayError := exception.Compile(exception.COMMON, appErr, lang, true)
 	statusStr := http.StatusText(statusCode)
 
 	jsonErrResp := &dto.HTTPErrorResp{
@@ -50,8 +53,18 @@ func (e *rest) httpRespError(c *gin.Context, appErr error) {
 			Message:    fmt.Sprintf("%s %s [%d] %s", c.Request.Method, c.Request.RequestURI, statusCode, http.StatusText(statusCode)),
 			Error:      &displayError,
 			Timestamp:  time.Now().Format(time.RFC3339),
+			RequestID:  config.RequestIDFromContext(c.Request.Context()),
 		},
 	}
 
 	c.JSON(statusCode, jsonErrResp)
 }
+
+// bindError keeps the validation failures apart from the bodies that could not be decoded.
+func bindError(err error, code exception.Code, msg string) error {
+	if config.IsValidationError(err) {
+		code = exception.CodeHTTPValidatorError
+	}
+
+	return exception.WrapWithCode(err, code, msg)
+}
@@ -18,7 +18,7 @@ func (r *rest) CreateUser(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("Invalid request body")
-		r.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPUnmarshal, "invalid_request_body"))
+		r.httpRespError(c, bindError(err, exception.CodeHTTPUnmarshal, "invalid_request_body"))
 		return
 	}
 
@@ -60,7 +60,7 @@ func (e *rest) ListUsers(c *gin.Context) {
 
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("invalid_query_parameters")
-		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPBadRequest, "invalid_query_parameters"))
+		e.httpRespError(c, bindError(err, exception.CodeHTTPBadRequest, "invalid_query_parameters"))
 		return
 	}
 
@@ -90,7 +90,7 @@ func (e *rest) UpdateUser(c *gin.Context) {
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("invalid_request_body")
-		e.httpRespError(c, exception.WrapWithCode(err, exception.CodeHTTPUnmarshal, "Invalid request body"))
+		e.httpRespError(c, bindError(err, exception.CodeHTTPUnmarshal, "Invalid request body"))
 		return
 	}
 
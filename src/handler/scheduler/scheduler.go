@@ -1 +1,23 @@
 package scheduler
+
+import (
+	"learngolang/src/config"
+	"learngolang/src/service"
+
+	"github.com/rs/zerolog"
+)
+
+// InitSchedulerHandler registers the enabled jobs, nothing is registered when
+// the scheduler is disabled.
+func InitSchedulerHandler(log zerolog.Logger, scheduler *config.Scheduler, service *service.Service, opt config.SchedulerJobsOptions) {
+	if scheduler == nil {
+		return
+	}
+
+	if opt.UserGeneratorJob.Enabled {
+		job := InitUserGeneratorJob(log, service.User, opt.UserGeneratorJob)
+		if err := scheduler.AddJob(job); err != nil {
+			log.Panic().Err(err).Str("job", job.Name()).Msg("Failed to register job")
+		}
+	}
+}
@@ -39,12 +39,17 @@ func (j *UserGeneratorJob) Schedule() string {
 }
 
 func (j *UserGeneratorJob) Run(ctx context.Context) error {
+	log := zerolog.Ctx(ctx)
+	if log.GetLevel() == zerolog.Disabled {
+		log = &j.log
+	}
+
 	if !j.config.Enabled {
-		j.log.Debug().Msg("UserGeneratorJob is disabled")
+		log.Debug().Msg("UserGeneratorJob is disabled")
 		return nil
 	}
 
-	j.log.Info().
+	log.Info().
 		Int("batch_size", j.config.BatchSize).
 		Msg("Generating random users")
 
@@ -60,7 +65,7 @@ func (j *UserGeneratorJob) Run(ctx context.Context) error {
 
 		_, err := j.userService.CreateUser(ctx, req)
 		if err != nil {
-			j.log.Warn().
+			log.Warn().
 				Err(err).
 				Str("email", user.Email).
 				Msg("Failed to create user")
@@ -68,14 +73,14 @@ func (j *UserGeneratorJob) Run(ctx context.Context) error {
 		}
 
 		successCount++
-		j.log.Debug().
+		log.Debug().
 			Str("name", user.Name).
 			Str("email", user.Email).
 			Int("age", user.Age).
 			Msg("User created successfully")
 	}
 
-	j.log.Info().
+	log.Info().
 		Int("success", successCount).
 		Int("total", j.config.BatchSize).
 		Msg("User generation batch completed")
@@ -17,22 +17,47 @@ const (
 	REDIS_AUTH    string = "AUTH"
 
 	// Logging Context Keys
-	CONTEXT_KEY_REQUEST_ID     contextKey = "requestID"
-	CONTEXT_KEY_LOG_REQUEST_ID contextKey = "req_id"
-	EVENT                      string     = "event"
-	METHOD                     string     = "method"
-	URL                        string     = "url"
-	ADDR                       string     = "addr"
-	STATUS                     string     = "status_code"
-	LATENCY                    string     = "latency"
-	USER_AGENT                 string     = "user_agent"
+	CONTEXT_KEY_REQUEST_ID      contextKey = "requestID"
+	CONTEXT_KEY_LOG_REQUEST_ID  contextKey = "req_id"
+	CONTEXT_KEY_TRACE_PARENT    contextKey = "traceParent"
+	CONTEXT_KEY_DEBUG_LOG       contextKey = "debugLog"
+	CONTEXT_KEY_USER            contextKey = "user"
+	CONTEXT_KEY_ADMIN           contextKey = "admin"
+	CONTEXT_KEY_CLIENT_IDENTITY contextKey = "clientIdentity"
+	TRACE_ID                    string     = "trace_id"
+	JOB                         string     = "job"
+	EVENT                       string     = "event"
+	METHOD                      string     = "method"
+	URL                         string     = "url"
+	ADDR                        string     = "addr"
+	STATUS                      string     = "status_code"
+	LATENCY                     string     = "latency"
+	USER_AGENT                  string     = "user_agent"
+	ROUTE                       string     = "route"
+	CLIENT_IP                   string     = "client_ip"
+	BYTES_IN                    string     = "bytes_in"
+	BYTES_OUT                   string     = "bytes_out"
+	USER                        string     = "user"
+	ERROR_CODE                  string     = "error_code"
+	SLOW                        string     = "slow"
+	REQUEST_BODY                string     = "request_body"
+	RESPONSE_BODY               string     = "response_body"
+	ERROR_DEBUG                 string     = "error_debug"
 
 	// Lang Header
 	LANG_EN string = `en`
 	LANG_ID string = `id`
 
 	// Custom HTTP Header
-	APP_LANG string = `x-app-lang`
+	APP_LANG            string = `x-app-lang`
+	IDEMPOTENCY_KEY     string = `Idempotency-Key`
+	IDEMPOTENT_REPLAYED string = `Idempotent-Replayed`
+	HEADER_REQUEST_ID   string = `X-Request-ID`
+	HEADER_TRACE_PARENT string = `traceparent`
+	// HEADER_DEBUG_LOG set to true by an authenticated admin logs a single request at debug level
+	HEADER_DEBUG_LOG string = `X-Debug-Log`
+	// HEADER_DEBUG_ERROR carries a signature allowing the debug output of the error response
+	HEADER_DEBUG_ERROR string = `X-Debug-Error`
 
 	// Cache Control Header
 	CacheControl        string = `cache-control`
@@ -14,13 +14,14 @@ type Repository struct {
 	User user.UserRepositoryItf
 }
 
-func InitRepository(sql0 *sqlx.DB, redis0 *redis.Client, queryLoader *config.QueryLoader, cacheTTL time.Duration) *Repository {
+func InitRepository(sql0 *sqlx.DB, redis0 *redis.Client, queryLoader *config.QueryLoader, cacheTTL time.Duration, metrics *config.Metrics) *Repository {
 	return &Repository{
 		User: user.InitUserRepository(
 			sql0,
 			redis0,
 			queryLoader,
 			cacheTTL,
+			metrics,
 		),
 	}
 }
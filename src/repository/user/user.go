@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"learngolang/src/config"
@@ -18,20 +19,32 @@ type UserRepositoryItf interface {
 	FindAll(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) ([]domain.User, dto.Pagination, error)
 	Update(ctx context.Context, id string, user domain.User) error
 	Delete(ctx context.Context, id string) error
+	PurgeCache(ctx context.Context) (int64, error)
+	SetCacheTTL(ttl time.Duration)
 }
 
 type userRepository struct {
 	sql0        *sqlx.DB
 	redis0      *redis.Client
 	queryLoader *config.QueryLoader
-	cacheTTL    time.Duration
+	cacheTTL    atomic.Int64
+	metrics     *config.Metrics
 }
 
-func InitUserRepository(sql0 *sqlx.DB, redis0 *redis.Client, queryLoader *config.QueryLoader, cacheTTL time.Duration) UserRepositoryItf {
-	return &userRepository{
+func InitUserRepository(sql0 *sqlx.DB, redis0 *redis.Client, queryLoader *config.QueryLoader, cacheTTL time.Duration, metrics *config.Metrics) UserRepositoryItf {
+	d := &userRepository{
 		sql0:        sql0,
 		redis0:      redis0,
 		queryLoader: queryLoader,
-		cacheTTL:    cacheTTL,
+		metrics:     metrics,
 	}
+
+	d.SetCacheTTL(cacheTTL)
+
+	return d
+}
+
+// SetCacheTTL changes the TTL of the entries cached from now on, it is called on config reload.
+func (d *userRepository) SetCacheTTL(ttl time.Duration) {
+	d.cacheTTL.Store(int64(ttl))
 }
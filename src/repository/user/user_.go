@@ -7,40 +7,44 @@ import (
 	"fmt"
 	"time"
 
+	"learngolang/src/config"
 	"learngolang/src/domain"
 	"learngolang/src/dto"
 	exception "learngolang/src/errors"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/rs/zerolog"
 )
 
-func (d *userRepository) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
+func (d *userRepository) Create(ctx context.Context, user *domain.User) (created *domain.User, err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.Create")
+	defer func() { config.EndSpan(span, err) }()
+
 	tx, err := d.sql0.BeginTxx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelDefault,
 	})
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("tx_create_user")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("tx_create_user")
 		return user, exception.Wrap(err, "tx_create_user")
 	}
 
 	tx, user, err = d.createSQLUser(ctx, tx, user)
 	if err != nil {
 		_ = tx.Rollback()
-		zerolog.Ctx(ctx).Error().Err(err).Msg("sql_create_user")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("sql_create_user")
 		return user, exception.Wrap(err, "sql_create_user")
 	}
 
 	if err = tx.Commit(); err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("commit_create_user")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("commit_create_user")
 		return user, exception.Wrap(err, "commit_create_user")
 	}
 
 	return user, nil
 }
 
-func (d *userRepository) FindByID(ctx context.Context, id string) (domain.User, error) {
-	var user domain.User
+func (d *userRepository) FindByID(ctx context.Context, id string) (user domain.User, err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.FindByID")
+	defer func() { config.EndSpan(span, err) }()
 
 	cacheKey := fmt.Sprintf("user:%s", id)
 
@@ -48,31 +52,39 @@ func (d *userRepository) FindByID(ctx context.Context, id string) (domain.User,
 	if err == nil {
 
 		if err := json.Unmarshal([]byte(cached), &user); err == nil {
-			zerolog.Ctx(ctx).Debug().Str("id", id).Msg("data_found_in_cache")
+			d.metrics.ObserveCache(cacheUserByID, config.MetricsCacheHit)
+			config.ComponentLogger(ctx, config.LogComponentRepository).Debug().Str("id", id).Msg("data_found_in_cache")
 			return user, nil
 		}
 	}
 
+	d.metrics.ObserveCache(cacheUserByID, config.MetricsCacheMiss)
+
 	query, _ := d.queryLoader.Get("FindUserByID")
 
-	err = d.sql0.GetContext(ctx, &user, query, id)
+	queryCtx, querySpan := d.queryLoader.StartSpan(ctx, "FindUserByID")
+	err = d.sql0.GetContext(queryCtx, &user, config.TagQuery(queryCtx, query), id)
+	config.EndQuerySpan(querySpan, err)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			zerolog.Ctx(ctx).Debug().Str("id", id).Msg("user_not_found")
+			config.ComponentLogger(ctx, config.LogComponentRepository).Debug().Str("id", id).Msg("user_not_found")
 			return user, exception.WrapWithCode(err, exception.CodeSQLEmptyRow, "user_not_found")
 		}
 
-		zerolog.Ctx(ctx).Error().Err(err).Str("id", id).Msg("find_user_err")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Str("id", id).Msg("find_user_err")
 		return user, exception.WrapWithCode(err, exception.CodeSQLRowScan, "find_user_err")
 	}
 
 	data, _ := json.Marshal(user)
-	d.redis0.Set(ctx, cacheKey, data, d.cacheTTL)
+	d.redis0.Set(ctx, cacheKey, data, time.Duration(d.cacheTTL.Load()))
 
 	return user, nil
 }
 
-func (d *userRepository) FindAll(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) ([]domain.User, dto.Pagination, error) {
+func (d *userRepository) FindAll(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) (users []domain.User, page dto.Pagination, err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.FindAll")
+	defer func() { config.EndSpan(span, err) }()
+
 	if cacheControl.MustRevalidate {
 		result, pagination, err := d.findAllSQLUser(ctx, filter)
 		if err != nil {
@@ -80,15 +92,21 @@ func (d *userRepository) FindAll(ctx context.Context, cacheControl dto.CacheCont
 		}
 
 		if err = d.setCacheFindAllUser(ctx, filter, result, pagination); err != nil {
-			zerolog.Ctx(ctx).Warn().Err(err).Send()
+			config.ComponentLogger(ctx, config.LogComponentRepository).Warn().Err(err).Send()
 		}
 
 		return result, pagination, nil
 	}
 
 	result, pagination, err := d.getCacheFindAllUser(ctx, filter)
+	if err == nil {
+		d.metrics.ObserveCache(cacheUserList, config.MetricsCacheHit)
+	} else {
+		d.metrics.ObserveCache(cacheUserList, config.MetricsCacheMiss)
+	}
+
 	if err == redis.Nil {
-		zerolog.Ctx(ctx).Warn().Err(err).Send()
+		config.ComponentLogger(ctx, config.LogComponentRepository).Warn().Err(err).Send()
 
 		result, pagination, err = d.findAllSQLUser(ctx, filter)
 		if err != nil {
@@ -96,12 +114,12 @@ func (d *userRepository) FindAll(ctx context.Context, cacheControl dto.CacheCont
 		}
 
 		if err = d.setCacheFindAllUser(ctx, filter, result, pagination); err != nil {
-			zerolog.Ctx(ctx).Warn().Err(err).Send()
+			config.ComponentLogger(ctx, config.LogComponentRepository).Warn().Err(err).Send()
 		}
 
 		return result, pagination, nil
 	} else if err != nil {
-		zerolog.Ctx(ctx).Warn().Err(err).Send()
+		config.ComponentLogger(ctx, config.LogComponentRepository).Warn().Err(err).Send()
 
 		// fallback if there is redis error e.g. bad conn, etc.
 		// this is quite critical during high load traffic since it could be
@@ -113,27 +131,32 @@ func (d *userRepository) FindAll(ctx context.Context, cacheControl dto.CacheCont
 	return result, pagination, nil
 }
 
-func (d *userRepository) Update(ctx context.Context, id string, user domain.User) error {
+func (d *userRepository) Update(ctx context.Context, id string, user domain.User) (err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.Update")
+	defer func() { config.EndSpan(span, err) }()
+
 	query, _ := d.queryLoader.Get("UpdateUser")
 
+	queryCtx, querySpan := d.queryLoader.StartSpan(ctx, "UpdateUser")
 	result, err := d.sql0.ExecContext(
-		ctx,
-		query,
+		queryCtx,
+		config.TagQuery(queryCtx, query),
 		user.Name,
 		user.Email,
 		user.Age,
 		time.Now(),
 		id,
 	)
+	config.EndQuerySpan(querySpan, err)
 
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Str("id", id).Msg("Failed to update user")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Str("id", id).Msg("Failed to update user")
 		return exception.WrapWithCode(err, exception.CodeSQLUpdate, "Failed to update user")
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		zerolog.Ctx(ctx).Debug().Str("id", id).Msg("User not found for update")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Debug().Str("id", id).Msg("User not found for update")
 		return exception.WrapWithCode(err, exception.CodeSQLEmptyRow, "User not found for update")
 	}
 
@@ -143,18 +166,23 @@ func (d *userRepository) Update(ctx context.Context, id string, user domain.User
 	return nil
 }
 
-func (d *userRepository) Delete(ctx context.Context, id string) error {
+func (d *userRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.Delete")
+	defer func() { config.EndSpan(span, err) }()
+
 	query, _ := d.queryLoader.Get("DeleteUser")
 
-	result, err := d.sql0.ExecContext(ctx, query, id)
+	queryCtx, querySpan := d.queryLoader.StartSpan(ctx, "DeleteUser")
+	result, err := d.sql0.ExecContext(queryCtx, config.TagQuery(queryCtx, query), id)
+	config.EndQuerySpan(querySpan, err)
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Str("id", id).Msg("Failed to delete user")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Str("id", id).Msg("Failed to delete user")
 		return exception.WrapWithCode(err, exception.CodeSQLDelete, "Failed to delete user")
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		zerolog.Ctx(ctx).Debug().Str("id", id).Msg("User not found for deletion")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Debug().Str("id", id).Msg("User not found for deletion")
 		return exception.WrapWithCode(err, exception.CodeSQLEmptyRow, "User not found")
 	}
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"learngolang/src/config"
 	"learngolang/src/domain"
 	"learngolang/src/dto"
 	exception "learngolang/src/errors"
@@ -16,7 +17,13 @@ import (
 const (
 	userByParamHashKey           string = "user:param"
 	userPaginationByParamHashKey string = "user:pagination"
+	userCacheKeyPattern          string = "user:*"
+	purgeCacheBatchSize          int64  = 500
 	durationUserExpiration              = 5 * time.Minute
+
+	// cache names used as metrics label
+	cacheUserByID string = "user_by_id"
+	cacheUserList string = "user_list"
 )
 
 func (d *userRepository) setCacheFindAllUser(ctx context.Context, filter dto.UserFilter, result []domain.User, pagination dto.Pagination) error {
@@ -117,3 +124,35 @@ func (d *userRepository) getCacheFindAllUser(ctx context.Context, filter dto.Use
 
 	return results, pagination, nil
 }
+
+// PurgeCache removes every cached user entry, both single users and list results.
+func (d *userRepository) PurgeCache(ctx context.Context) (purged int64, err error) {
+	ctx, span := config.StartSpan(ctx, "UserRepository.PurgeCache")
+	defer func() { config.EndSpan(span, err) }()
+
+	var (
+		cursor  uint64
+		deleted int64
+	)
+
+	for {
+		keys, next, err := d.redis0.Scan(ctx, cursor, userCacheKeyPattern, purgeCacheBatchSize).Result()
+		if err != nil {
+			return deleted, exception.WrapWithCode(err, exception.CodeCacheGetSimpleKey, "purge_cache_scan")
+		}
+
+		if len(keys) > 0 {
+			count, err := d.redis0.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, exception.WrapWithCode(err, exception.CodeCacheDeleteSimpleKey, "purge_cache_delete")
+			}
+
+			deleted += count
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
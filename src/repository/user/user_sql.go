@@ -3,18 +3,20 @@ package user
 import (
 	"context"
 
+	"learngolang/src/config"
 	"learngolang/src/domain"
 	"learngolang/src/dto"
 	exception "learngolang/src/errors"
 	"learngolang/src/util"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/rs/zerolog"
 )
 
 func (d *userRepository) createSQLUser(ctx context.Context, tx *sqlx.Tx, user *domain.User) (*sqlx.Tx, *domain.User, error) {
 	query, _ := d.queryLoader.Get("CreateUser")
-	row := tx.QueryRowContext(ctx, query, user.Name, user.Email, user.Age).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	queryCtx, querySpan := d.queryLoader.StartSpan(ctx, "CreateUser")
+	row := tx.QueryRowContext(queryCtx, config.TagQuery(queryCtx, query), user.Name, user.Email, user.Age).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	config.EndQuerySpan(querySpan, row)
 	if err := row; err != nil {
 		return tx, user, exception.Wrap(err, "create_sql_user")
 	}
@@ -60,30 +62,34 @@ func (d *userRepository) findAllSQLUser(ctx context.Context, filter dto.UserFilt
 	// Get users
 	query, args, err := d.queryLoader.ExecuteTemplate("FindAllUsersBase", templateData)
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("build_find_users_query_err")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("build_find_users_query_err")
 		return nil, pagination, exception.WrapWithCode(err, exception.CodeSQLQueryBuild, "build_find_users_query_err")
 	}
 
-	err = d.sql0.SelectContext(ctx, &results, query, args...)
+	queryCtx, querySpan := d.queryLoader.StartSpan(ctx, "FindAllUsersBase")
+	err = d.sql0.SelectContext(queryCtx, &results, config.TagQuery(queryCtx, query), args...)
+	config.EndQuerySpan(querySpan, err)
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("find_users_err")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("find_users_err")
 		return nil, pagination, exception.WrapWithCode(err, exception.CodeSQLRowScan, "find_users_err")
 	}
 
 	// Count users
 	countQuery, countArgs, err := d.queryLoader.ExecuteTemplate("CountUsersBase", templateData)
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("count_users_query_err")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("count_users_query_err")
 		return nil, pagination, exception.WrapWithCode(err, exception.CodeSQLQueryBuild, "count_users_query_err")
 	}
 
-	err = d.sql0.GetContext(ctx, &totalRecords, countQuery, countArgs...)
+	queryCtx, querySpan = d.queryLoader.StartSpan(ctx, "CountUsersBase")
+	err = d.sql0.GetContext(queryCtx, &totalRecords, config.TagQuery(queryCtx, countQuery), countArgs...)
+	config.EndQuerySpan(querySpan, err)
 	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err).Msg("count_users_err")
+		config.ComponentLogger(ctx, config.LogComponentRepository).Error().Err(err).Msg("count_users_err")
 		return nil, pagination, exception.WrapWithCode(err, exception.CodeSQLRowScan, "count_users_err")
 	}
 
-	zerolog.Ctx(ctx).Debug().Int64("total", totalRecords).Msg("total_users_found")
+	config.ComponentLogger(ctx, config.LogComponentRepository).Debug().Int64("total", totalRecords).Msg("total_users_found")
 
 	// Update Pagination
 	totalPage := totalRecords / filter.PageSize
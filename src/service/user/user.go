@@ -14,6 +14,7 @@ type UserServiceItf interface {
 	ListUsers(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) ([]domain.User, dto.Pagination, error)
 	UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) (domain.User, error)
 	DeleteUser(ctx context.Context, id string) error
+	PurgeCache(ctx context.Context) (int64, error)
 }
 
 type userService struct {
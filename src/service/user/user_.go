@@ -3,33 +3,46 @@ package user
 import (
 	"context"
 
+	"learngolang/src/config"
 	"learngolang/src/domain"
 	"learngolang/src/dto"
 )
 
-func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest) (*domain.User, error) {
-	user := &domain.User{
+func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest) (user *domain.User, err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.CreateUser")
+	defer func() { config.EndSpan(span, err) }()
+
+	user = &domain.User{
 		Name:  req.Name,
 		Email: req.Email,
 		Age:   req.Age,
 	}
 
-	if _, err := s.userRepository.Create(ctx, user); err != nil {
+	if _, err = s.userRepository.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *userService) GetUser(ctx context.Context, id string) (domain.User, error) {
+func (s *userService) GetUser(ctx context.Context, id string) (user domain.User, err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.GetUser")
+	defer func() { config.EndSpan(span, err) }()
+
 	return s.userRepository.FindByID(ctx, id)
 }
 
-func (s *userService) ListUsers(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) ([]domain.User, dto.Pagination, error) {
+func (s *userService) ListUsers(ctx context.Context, cacheControl dto.CacheControl, filter dto.UserFilter) (users []domain.User, pagination dto.Pagination, err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.ListUsers")
+	defer func() { config.EndSpan(span, err) }()
+
 	return s.userRepository.FindAll(ctx, cacheControl, filter)
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) (domain.User, error) {
+func (s *userService) UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) (user domain.User, err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.UpdateUser")
+	defer func() { config.EndSpan(span, err) }()
+
 	existingUser, err := s.userRepository.FindByID(ctx, id)
 	if err != nil {
 		return existingUser, err
@@ -47,13 +60,23 @@ func (s *userService) UpdateUser(ctx context.Context, id string, req dto.UpdateU
 		existingUser.Age = req.Age
 	}
 
-	if err := s.userRepository.Update(ctx, id, existingUser); err != nil {
+	if err = s.userRepository.Update(ctx, id, existingUser); err != nil {
 		return existingUser, err
 	}
 
 	return s.userRepository.FindByID(ctx, id)
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id string) error {
+func (s *userService) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.DeleteUser")
+	defer func() { config.EndSpan(span, err) }()
+
 	return s.userRepository.Delete(ctx, id)
 }
+
+func (s *userService) PurgeCache(ctx context.Context) (purged int64, err error) {
+	ctx, span := config.StartSpan(ctx, "UserService.PurgeCache")
+	defer func() { config.EndSpan(span, err) }()
+
+	return s.userRepository.PurgeCache(ctx)
+}